@@ -0,0 +1,159 @@
+package selenium
+
+import (
+	"fmt"
+	"time"
+)
+
+// webElementKey identifies an element reference object in the W3C protocol.
+const webElementKey = "element-6066-11e4-a52e-4f735466cecf"
+
+/* Pointer types for input sources. */
+const (
+	MousePointer = "mouse"
+	PenPointer   = "pen"
+	TouchPointer = "touch"
+)
+
+// Actions builds a sequence of W3C input actions, see
+// https://www.w3.org/TR/webdriver/#actions. Every input source ticks in
+// lockstep: the n-th action of each source is dispatched in the same tick,
+// so use Pause to keep sources aligned. Obtain one with WebDriver.Actions.
+type Actions struct {
+	wd      *remoteWebDriver
+	sources []*InputSource
+	// err is an invalid gesture, returned by Perform.
+	err error
+}
+
+// InputSource is a single keyboard or pointer device of an Actions sequence.
+type InputSource struct {
+	id, typ, pointerType string
+	actions              []map[string]interface{}
+}
+
+func (a *Actions) addSource(id, typ, pointerType string) *InputSource {
+	s := &InputSource{id: id, typ: typ, pointerType: pointerType}
+	a.sources = append(a.sources, s)
+	return s
+}
+
+// Key declares a keyboard input source.
+func (a *Actions) Key(id string) *InputSource {
+	return a.addSource(id, "key", "")
+}
+
+// Pointer declares a pointer input source of the given pointer type.
+func (a *Actions) Pointer(id, pointerType string) *InputSource {
+	return a.addSource(id, "pointer", pointerType)
+}
+
+// Touch declares a touch pointer. Declare several to perform multi-touch
+// gestures.
+func (a *Actions) Touch(id string) *InputSource {
+	return a.Pointer(id, TouchPointer)
+}
+
+// Pinch adds two touch pointers that pinch the center of elem, spreading
+// apart when scale > 1 (zoom in) and closing when scale < 1 (zoom out).
+// scale must be positive, else Perform fails.
+func (a *Actions) Pinch(elem WebElement, scale float64) *Actions {
+	if scale <= 0 {
+		if a.err == nil {
+			a.err = fmt.Errorf("invalid pinch scale %g", scale)
+		}
+		return a
+	}
+	const offset = 50
+	end := int(offset * scale)
+	duration := 250 * time.Millisecond
+	// Source ids must be unique, also when pinching more than once.
+	id := fmt.Sprintf("pinch%d-finger", len(a.sources))
+	a.Touch(id+"1").PointerMove(elem, -offset, 0, 0).PointerDown(LeftButton).
+		PointerMove(elem, -end, 0, duration).PointerUp(LeftButton)
+	a.Touch(id+"2").PointerMove(elem, offset, 0, 0).PointerDown(LeftButton).
+		PointerMove(elem, end, 0, duration).PointerUp(LeftButton)
+	return a
+}
+
+// Perform sends the actions to the server, padding shorter sources with
+// pauses so that all sources have the same number of ticks.
+func (a *Actions) Perform() error {
+	if a.err != nil {
+		return a.err
+	}
+	ticks := 0
+	for _, s := range a.sources {
+		if len(s.actions) > ticks {
+			ticks = len(s.actions)
+		}
+	}
+	sources := make([]map[string]interface{}, len(a.sources))
+	for i, s := range a.sources {
+		actions := s.actions
+		for len(actions) < ticks {
+			actions = append(actions, map[string]interface{}{"type": "pause", "duration": 0})
+		}
+		source := map[string]interface{}{
+			"type":    s.typ,
+			"id":      s.id,
+			"actions": actions,
+		}
+		if s.pointerType != "" {
+			source["parameters"] = map[string]string{"pointerType": s.pointerType}
+		}
+		sources[i] = source
+	}
+	return a.wd.voidCommand("Perform", "/session/%s/actions", map[string]interface{}{"actions": sources})
+}
+
+func (s *InputSource) add(action map[string]interface{}) *InputSource {
+	s.actions = append(s.actions, action)
+	return s
+}
+
+// Pause idles the source for one tick.
+func (s *InputSource) Pause(d time.Duration) *InputSource {
+	return s.add(map[string]interface{}{"type": "pause", "duration": millis(d)})
+}
+
+// KeyDown presses key, which should be a single character or one of the key
+// constants.
+func (s *InputSource) KeyDown(key string) *InputSource {
+	return s.add(map[string]interface{}{"type": "keyDown", "value": key})
+}
+
+// KeyUp releases key.
+func (s *InputSource) KeyUp(key string) *InputSource {
+	return s.add(map[string]interface{}{"type": "keyUp", "value": key})
+}
+
+// PointerMove moves the pointer to the offset from the center of origin, or
+// from the top-left of the viewport if origin is nil.
+func (s *InputSource) PointerMove(origin WebElement, xOffset, yOffset int, d time.Duration) *InputSource {
+	action := map[string]interface{}{
+		"type":     "pointerMove",
+		"x":        xOffset,
+		"y":        yOffset,
+		"duration": millis(d),
+		"origin":   "viewport",
+	}
+	if elem, ok := origin.(*remoteWE); ok {
+		action["origin"] = map[string]string{webElementKey: elem.id}
+	}
+	return s.add(action)
+}
+
+// PointerDown presses button, one of LeftButton, MiddleButton or RightButton.
+func (s *InputSource) PointerDown(button int) *InputSource {
+	return s.add(map[string]interface{}{"type": "pointerDown", "button": button})
+}
+
+// PointerUp releases button.
+func (s *InputSource) PointerUp(button int) *InputSource {
+	return s.add(map[string]interface{}{"type": "pointerUp", "button": button})
+}
+
+func millis(d time.Duration) int64 {
+	return int64(d / time.Millisecond)
+}
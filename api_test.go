@@ -1,11 +1,32 @@
 package selenium
 
 import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"image/color"
+	"io"
+	"io/ioutil"
+	"log"
+	"net"
 	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"reflect"
+	"runtime"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 )
 
 func TestExecuteScript_Args(t *testing.T) {
@@ -57,3 +78,3744 @@ func TestExecuteScript_NoArgs(t *testing.T) {
 
 	client.ExecuteScript("return 'foo'", nil)
 }
+
+func TestExecuteScriptInto(t *testing.T) {
+	setup()
+	defer teardown()
+
+	mux.HandleFunc("/session/123/execute", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"status": 0, "value": {"width": 1024, "height": 768, "title": "Home"}}`)
+	})
+
+	var v struct {
+		Width, Height int
+		Title         string
+	}
+	script := "return {width: innerWidth, height: innerHeight, title: document.title}"
+	if err := client.ExecuteScriptInto(script, nil, &v); err != nil {
+		t.Fatalf("ExecuteScriptInto returned error: %v", err)
+	}
+	if v.Width != 1024 || v.Height != 768 || v.Title != "Home" {
+		t.Errorf("ExecuteScriptInto decoded %+v", v)
+	}
+}
+
+func TestExecuteScript_ElementArgs(t *testing.T) {
+	setup()
+	defer teardown()
+
+	var got map[string]interface{}
+	mux.HandleFunc("/session/123/execute", func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&got)
+		fmt.Fprint(w, `{"status": 0, "value": null}`)
+	})
+
+	wd := client.(*remoteWebDriver)
+	e1, e2 := &remoteWE{wd, "e1"}, &remoteWE{wd, "e2"}
+	args := []interface{}{[]WebElement{e1, e2}, map[string]interface{}{"target": e1}, "text"}
+	if _, err := client.ExecuteScript("arguments[0].forEach(...)", args); err != nil {
+		t.Fatalf("ExecuteScript returned error: %v", err)
+	}
+
+	ref := func(id string) map[string]interface{} {
+		return map[string]interface{}{"ELEMENT": id, webElementKey: id}
+	}
+	want := []interface{}{
+		[]interface{}{ref("e1"), ref("e2")},
+		map[string]interface{}{"target": ref("e1")},
+		"text",
+	}
+	if !reflect.DeepEqual(got["args"], want) {
+		t.Errorf("ExecuteScript sent args %v, want %v", got["args"], want)
+	}
+	if args[1].(map[string]interface{})["target"] != e1 {
+		t.Error("ExecuteScript modified its arguments")
+	}
+}
+
+func TestExecuteScript_Elements(t *testing.T) {
+	setupW3C()
+	defer teardown()
+
+	var value string
+	mux.HandleFunc("/session/123/execute", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"value": %s}`, value)
+	})
+
+	value = `{"element-6066-11e4-a52e-4f735466cecf": "e1"}`
+	res, err := client.ExecuteScript("return document.body", nil)
+	if err != nil {
+		t.Fatalf("ExecuteScript returned error: %v", err)
+	}
+	if elem, ok := res.(*remoteWE); !ok || elem.id != "e1" {
+		t.Errorf("ExecuteScript returned %#v, want element e1", res)
+	}
+
+	value = `[{"ELEMENT": "e1"}, {"nested": {"element-6066-11e4-a52e-4f735466cecf": "e2"}}, {"ELEMENT": 3}]`
+	res, err = client.ExecuteScript("return [...]", nil)
+	if err != nil {
+		t.Fatalf("ExecuteScript returned error: %v", err)
+	}
+	wd := client.(*remoteWebDriver)
+	want := []interface{}{
+		&remoteWE{wd, "e1"},
+		map[string]interface{}{"nested": &remoteWE{wd, "e2"}},
+		map[string]interface{}{"ELEMENT": float64(3)},
+	}
+	if !reflect.DeepEqual(res, want) {
+		t.Errorf("ExecuteScript returned %#v, want %#v", res, want)
+	}
+}
+
+func TestPerformanceMetrics_NotSupported(t *testing.T) {
+	setup()
+	defer teardown()
+
+	mux.HandleFunc("/session/123/chromium/send_command_and_get_result", func(w http.ResponseWriter, r *http.Request) {
+		t.Error("CDP command sent to a non-chrome driver")
+	})
+
+	wd, err := NewRemote(Capabilities{"browserName": "firefox"}, server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := wd.PerformanceMetrics(); err != ErrNotSupported {
+		t.Errorf("PerformanceMetrics returned error %v, want %v", err, ErrNotSupported)
+	}
+}
+
+func TestUploadFile(t *testing.T) {
+	setup()
+	defer teardown()
+
+	dir, err := ioutil.TempDir("", "selenium")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "upload.txt")
+	if err := ioutil.WriteFile(path, []byte("hello"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	mux.HandleFunc("/session/123/file", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "POST")
+
+		var v map[string]string
+		json.NewDecoder(r.Body).Decode(&v)
+		data, err := base64.StdEncoding.DecodeString(v["file"])
+		if err != nil {
+			t.Fatalf("file is not base64 encoded: %s", err)
+		}
+		zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+		if err != nil {
+			t.Fatalf("file is not a zip archive: %s", err)
+		}
+		if len(zr.File) != 1 || zr.File[0].Name != "upload.txt" {
+			t.Fatalf("Archive files = %+v, want [upload.txt]", zr.File)
+		}
+		rc, _ := zr.File[0].Open()
+		content, _ := ioutil.ReadAll(rc)
+		rc.Close()
+		if string(content) != "hello" {
+			t.Errorf("File content = %q, want %q", content, "hello")
+		}
+
+		fmt.Fprint(w, `{"status": 0, "value": "/remote/upload.txt"}`)
+	})
+
+	remotePath, err := client.UploadFile(path)
+	if err != nil {
+		t.Fatalf("UploadFile returned error: %v", err)
+	}
+	if want := "/remote/upload.txt"; remotePath != want {
+		t.Errorf("UploadFile returned %q, want %q", remotePath, want)
+	}
+}
+
+func TestActions_Perform(t *testing.T) {
+	setup()
+	defer teardown()
+
+	mux.HandleFunc("/session/123/actions", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "POST")
+
+		var v struct {
+			Actions []struct {
+				Type       string
+				ID         string
+				Parameters map[string]string
+				Actions    []map[string]interface{}
+			}
+		}
+		json.NewDecoder(r.Body).Decode(&v)
+
+		if len(v.Actions) != 2 {
+			t.Fatalf("Got %d input sources, want 2", len(v.Actions))
+		}
+		for _, s := range v.Actions {
+			if s.Type != "pointer" || s.Parameters["pointerType"] != "touch" {
+				t.Errorf("Source %q: type %q %+v, want touch pointer", s.ID, s.Type, s.Parameters)
+			}
+			if len(s.Actions) != 3 {
+				t.Errorf("Source %q has %d ticks, want 3", s.ID, len(s.Actions))
+			}
+		}
+		if pad := v.Actions[1].Actions[2]; pad["type"] != "pause" {
+			t.Errorf("Shorter source padded with %+v, want a pause", pad)
+		}
+
+		fmt.Fprint(w, `{"status": 0, "value": null}`)
+	})
+
+	a := client.Actions()
+	a.Touch("finger1").PointerMove(nil, 10, 10, 0).PointerDown(LeftButton).PointerUp(LeftButton)
+	a.Touch("finger2").PointerMove(nil, 20, 20, 0).PointerDown(LeftButton)
+	if err := a.Perform(); err != nil {
+		t.Errorf("Perform returned error: %v", err)
+	}
+}
+
+func TestActions_Pinch(t *testing.T) {
+	setupW3C()
+	defer teardown()
+
+	var ids []string
+	mux.HandleFunc("/session/123/actions", func(w http.ResponseWriter, r *http.Request) {
+		var v struct {
+			Actions []struct {
+				ID string
+			}
+		}
+		json.NewDecoder(r.Body).Decode(&v)
+		for _, s := range v.Actions {
+			ids = append(ids, s.ID)
+		}
+		fmt.Fprint(w, `{"value": null}`)
+	})
+
+	elem := &remoteWE{client.(*remoteWebDriver), "e1"}
+	if err := client.Actions().Pinch(elem, 2).Pinch(elem, 0.5).Perform(); err != nil {
+		t.Fatalf("Perform returned error: %v", err)
+	}
+	seen := make(map[string]bool)
+	for _, id := range ids {
+		if seen[id] {
+			t.Errorf("Source id %q sent twice in %q", id, ids)
+		}
+		seen[id] = true
+	}
+	if len(ids) != 4 {
+		t.Errorf("Got sources %q, want 4", ids)
+	}
+
+	ids = nil
+	for _, scale := range []float64{0, -1} {
+		if err := client.Actions().Pinch(elem, scale).Perform(); err == nil {
+			t.Errorf("Perform of a pinch with scale %g returned no error", scale)
+		}
+	}
+	if len(ids) != 0 {
+		t.Errorf("Invalid pinches sent sources %q", ids)
+	}
+}
+
+func TestSetTimeouts(t *testing.T) {
+	setupW3C()
+	defer teardown()
+
+	var got []map[string]interface{}
+	mux.HandleFunc("/session/123/timeouts", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "POST")
+
+		var v map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&v)
+		got = append(got, v)
+
+		fmt.Fprint(w, `{"value": null}`)
+	})
+
+	if err := client.SetTimeouts(Timeouts{Implicit: 100, PageLoad: 200, Script: 300}); err != nil {
+		t.Fatalf("SetTimeouts returned error: %v", err)
+	}
+	if err := client.SetTimeout("page load", 400); err != nil {
+		t.Fatalf("SetTimeout returned error: %v", err)
+	}
+
+	want := []map[string]interface{}{
+		{"implicit": 100.0, "pageLoad": 200.0, "script": 300.0},
+		{"pageLoad": 400.0},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Request bodies = %+v, want %+v", got, want)
+	}
+}
+
+func TestSetTimeout_JSONWire(t *testing.T) {
+	setup()
+	defer teardown()
+
+	mux.HandleFunc("/session/123/timeouts", func(w http.ResponseWriter, r *http.Request) {
+		var v map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&v)
+
+		want := map[string]interface{}{"type": "page load", "ms": 400.0}
+		if !reflect.DeepEqual(v, want) {
+			t.Errorf("Request body = %+v, want %+v", v, want)
+		}
+
+		fmt.Fprint(w, `{"status": 0}`)
+	})
+
+	if err := client.SetTimeout("page load", 400); err != nil {
+		t.Fatalf("SetTimeout returned error: %v", err)
+	}
+}
+
+func TestSupports(t *testing.T) {
+	tests := []struct {
+		name                string
+		wd                  *remoteWebDriver
+		cdp, print, actions bool
+	}{
+		{
+			name: "chrome",
+			wd: &remoteWebDriver{w3c: true, sessionCaps: Capabilities{
+				"browserName":        "chrome",
+				"goog:chromeOptions": map[string]interface{}{},
+			}},
+			cdp: true, print: true, actions: true,
+		},
+		{
+			name: "firefox",
+			wd: &remoteWebDriver{w3c: true, sessionCaps: Capabilities{
+				"browserName":            "firefox",
+				"moz:geckodriverVersion": "0.33.0",
+			}},
+			cdp: false, print: true, actions: true,
+		},
+		{
+			name: "legacy firefox",
+			wd:   &remoteWebDriver{sessionCaps: Capabilities{"browserName": "firefox"}},
+			cdp:  false, print: false, actions: false,
+		},
+		{
+			name: "requested chrome",
+			wd:   &remoteWebDriver{capabilities: Capabilities{"browserName": "chrome"}},
+			cdp:  true, print: false, actions: false,
+		},
+	}
+	for _, test := range tests {
+		if got := test.wd.SupportsCDP(); got != test.cdp {
+			t.Errorf("%s: SupportsCDP = %v, want %v", test.name, got, test.cdp)
+		}
+		if got := test.wd.SupportsPrint(); got != test.print {
+			t.Errorf("%s: SupportsPrint = %v, want %v", test.name, got, test.print)
+		}
+		if got := test.wd.SupportsActions(); got != test.actions {
+			t.Errorf("%s: SupportsActions = %v, want %v", test.name, got, test.actions)
+		}
+	}
+}
+
+func TestWaitForServer(t *testing.T) {
+	requests := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/wd/hub/status" {
+			t.Errorf("Request path = %q, want /wd/hub/status", r.URL.Path)
+		}
+		requests++
+		if requests <= 2 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		fmt.Fprint(w, `{"value": {"ready": true}}`)
+	}))
+	defer srv.Close()
+
+	if err := WaitForServer(srv.URL+"/wd/hub", 5*time.Second); err != nil {
+		t.Fatalf("WaitForServer returned error: %v", err)
+	}
+	if requests != 3 {
+		t.Errorf("Got %d status requests, want 3", requests)
+	}
+}
+
+func TestWaitForServer_Timeout(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"value": {"ready": false}}`)
+	}))
+	defer srv.Close()
+
+	if err := WaitForServer(srv.URL, 300*time.Millisecond); err == nil {
+		t.Fatal("WaitForServer returned no error for a server that is never ready")
+	}
+}
+
+func TestWaitForServer_NoReply(t *testing.T) {
+	done := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-done
+	}))
+	defer srv.Close()
+	defer close(done)
+
+	start := time.Now()
+	if err := WaitForServer(srv.URL, 300*time.Millisecond); err == nil {
+		t.Fatal("WaitForServer returned no error for a server that never replies")
+	}
+	if d := time.Since(start); d > 2*time.Second {
+		t.Errorf("WaitForServer took %s with a timeout of 300ms", d)
+	}
+}
+
+// stubDriver writes a shell script standing in for a driver binary.
+func stubDriver(t *testing.T, script string) string {
+	if runtime.GOOS == "windows" {
+		t.Skip("stub driver is a shell script")
+	}
+	path := filepath.Join(t.TempDir(), "driver")
+	if err := ioutil.WriteFile(path, []byte("#!/bin/sh\n"+script+"\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestDriverService(t *testing.T) {
+	// The stub doesn't listen, so the service's port is served by a test
+	// server instead.
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"value": {"ready": true}}`)
+	}))
+	defer srv.Close()
+	port := srv.Listener.Addr().(*net.TCPAddr).Port
+
+	s, err := NewChromeDriverService(stubDriver(t, "exec sleep 30"), port)
+	if err != nil {
+		t.Fatalf("NewChromeDriverService returned error: %v", err)
+	}
+	if want := fmt.Sprintf("http://127.0.0.1:%d", port); s.URL() != want {
+		t.Errorf("URL = %q, want %q", s.URL(), want)
+	}
+	if err := s.Stop(); err != nil {
+		t.Errorf("Stop returned error: %v", err)
+	}
+}
+
+func TestDriverService_Exited(t *testing.T) {
+	start := time.Now()
+	_, err := NewChromeDriverService(stubDriver(t, "exit 3"), 0, ServiceStartTimeout(10*time.Second))
+	if err == nil {
+		t.Fatal("NewChromeDriverService returned no error for a driver that exited")
+	}
+	if !strings.Contains(err.Error(), "exit status 3") {
+		t.Errorf("NewChromeDriverService returned error %q, want the exit status", err)
+	}
+	if d := time.Since(start); d > 5*time.Second {
+		t.Errorf("NewChromeDriverService took %s to notice the driver exited", d)
+	}
+}
+
+func TestDriverService_ExitedWhileWaiting(t *testing.T) {
+	// The status request to the driver's port never gets a reply.
+	done := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-done
+	}))
+	defer srv.Close()
+	defer close(done)
+	port := srv.Listener.Addr().(*net.TCPAddr).Port
+
+	start := time.Now()
+	_, err := NewChromeDriverService(stubDriver(t, "sleep 1; exit 3"), port, ServiceStartTimeout(20*time.Second))
+	if err == nil || !strings.Contains(err.Error(), "exit status 3") {
+		t.Errorf("NewChromeDriverService returned error %v, want the exit status", err)
+	}
+	if d := time.Since(start); d > 5*time.Second {
+		t.Errorf("NewChromeDriverService took %s to notice the driver exited", d)
+	}
+}
+
+func TestAttachToSession(t *testing.T) {
+	setup()
+	defer teardown()
+
+	mux.HandleFunc("/session/456/url", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		fmt.Fprint(w, `{"sessionId": "456", "status": 0, "value": "about:blank"}`)
+	})
+	mux.HandleFunc("/session/456/title", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"sessionId": "456", "status": 0, "value": "attached"}`)
+	})
+	mux.HandleFunc("/session/456", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		fmt.Fprint(w, `{"sessionId": "456", "status": 0, "value": {"browserName": "chrome"}}`)
+	})
+
+	wd, err := AttachToSession(server.URL, "456")
+	if err != nil {
+		t.Fatalf("AttachToSession returned error: %v", err)
+	}
+	if id := wd.GetSessionID(); id != "456" {
+		t.Errorf("GetSessionID = %q, want %q", id, "456")
+	}
+	if title, err := wd.Title(); err != nil || title != "attached" {
+		t.Errorf("Title = %q, %v, want %q", title, err, "attached")
+	}
+	if !wd.SupportsCDP() {
+		t.Error("SupportsCDP = false for an attached Chrome session")
+	}
+}
+
+func TestAttachToSession_W3C(t *testing.T) {
+	setup()
+	defer teardown()
+
+	mux.HandleFunc("/session/456/url", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"value": "about:blank"}`)
+	})
+	mux.HandleFunc("/session/456", func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("Got %s %s, which W3C servers don't support", r.Method, r.URL.Path)
+	})
+
+	wd, err := AttachToSession(server.URL, "456")
+	if err != nil {
+		t.Fatalf("AttachToSession returned error: %v", err)
+	}
+	if wd.SupportsCDP() {
+		t.Error("SupportsCDP = true without capabilities")
+	}
+	if _, err := wd.SessionCapabilities(); err != ErrNotSupported {
+		t.Errorf("SessionCapabilities returned error %v, want %v", err, ErrNotSupported)
+	}
+
+	caps := Capabilities{"browserName": "chrome", "browserVersion": "120.0"}
+	wd, err = AttachToSession(server.URL, "456", AttachCapabilities(caps))
+	if err != nil {
+		t.Fatalf("AttachToSession returned error: %v", err)
+	}
+	if !wd.SupportsCDP() {
+		t.Error("SupportsCDP = false for an attached Chrome session")
+	}
+	info, err := wd.SessionCapabilities()
+	if err != nil {
+		t.Fatalf("SessionCapabilities returned error: %v", err)
+	}
+	if info.BrowserName != "chrome" || info.BrowserVersion != "120.0" {
+		t.Errorf("SessionCapabilities = %+v, want chrome 120.0", info)
+	}
+}
+
+func TestAttachToSession_Dead(t *testing.T) {
+	setup()
+	defer teardown()
+
+	mux.HandleFunc("/session/789/url", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprint(w, `{"status": 6, "value": {"message": "session not found"}}`)
+	})
+
+	if _, err := AttachToSession(server.URL, "789"); err == nil {
+		t.Fatal("AttachToSession returned no error for a dead session")
+	}
+}
+
+func TestAttachToSession_EmptyReply(t *testing.T) {
+	setup()
+	defer teardown()
+
+	for id, body := range map[string]string{"1": "", "2": "null"} {
+		body := body
+		mux.HandleFunc("/session/"+id+"/url", func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			fmt.Fprint(w, body)
+		})
+		if _, err := AttachToSession(server.URL, id); err == nil {
+			t.Errorf("AttachToSession returned no error for the reply %q", body)
+		}
+	}
+}
+
+func TestSetCommandContext(t *testing.T) {
+	setup()
+	defer teardown()
+
+	mux.HandleFunc("/session/123", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "DELETE" {
+			t.Error("Session deleted after a command was canceled")
+		}
+	})
+	block := make(chan struct{})
+	mux.HandleFunc("/session/123/title", func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-block:
+		case <-time.After(5 * time.Second):
+		}
+		fmt.Fprint(w, `{"status": 0, "value": "title"}`)
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	client.SetCommandContext(ctx)
+	if _, err := client.Title(); err != ErrCanceled {
+		t.Fatalf("Title returned error %v, want %v", err, ErrCanceled)
+	}
+
+	close(block)
+	client.SetCommandContext(nil)
+	if title, err := client.Title(); err != nil || title != "title" {
+		t.Fatalf("Title after cancellation = %q, %v, want %q", title, err, "title")
+	}
+}
+
+func TestSimple(t *testing.T) {
+	setup()
+	defer teardown()
+
+	mux.HandleFunc("/session/123", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "DELETE" {
+			t.Error("Session deleted by a command of the simple driver")
+		}
+	})
+	mux.HandleFunc("/session/123/title", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"status": 0, "value": "title"}`)
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	client.SetCommandContext(ctx)
+	defer client.SetCommandContext(nil)
+	simple := client.Simple()
+	if _, err := client.Title(); err != ErrCanceled {
+		t.Fatalf("Title returned error %v, want %v", err, ErrCanceled)
+	}
+	if title, err := simple.Title(); err != nil || title != "title" {
+		t.Fatalf("Title of the simple driver = %q, %v, want %q", title, err, "title")
+	}
+
+	client.SetContext(ctx)
+	defer client.SetContext(context.Background())
+	if title, err := simple.Title(); err != nil || title != "title" {
+		t.Fatalf("Title of the simple driver after SetContext = %q, %v, want %q", title, err, "title")
+	}
+}
+
+func TestConcurrentCommands(t *testing.T) {
+	setup()
+	defer teardown()
+
+	mux.HandleFunc("/session/123/title", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"status": 0, "value": "title"}`)
+	})
+	mux.HandleFunc("/session/123/url", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"status": 0, "value": "http://example.com/"}`)
+	})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 10; j++ {
+				if title, err := client.Title(); err != nil || title != "title" {
+					t.Errorf("Title = %q, %v, want %q", title, err, "title")
+				}
+				if url, err := client.CurrentURL(); err != nil || url != "http://example.com/" {
+					t.Errorf("CurrentURL = %q, %v, want %q", url, err, "http://example.com/")
+				}
+				client.SetCommandContext(context.Background())
+			}
+		}()
+	}
+	wg.Wait()
+	client.SetCommandContext(nil)
+}
+
+func TestSetContext_QuitsSession(t *testing.T) {
+	setup()
+	defer teardown()
+
+	deleted := false
+	mux.HandleFunc("/session/123", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "DELETE")
+		deleted = true
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	client.SetContext(ctx)
+	cancel()
+	if _, err := client.Title(); err != ErrCanceled {
+		t.Fatalf("Title returned error %v, want %v", err, ErrCanceled)
+	}
+	if !deleted {
+		t.Error("Session not deleted after the driver context was canceled")
+	}
+}
+
+func TestKeepSessionOnCancel(t *testing.T) {
+	setup()
+	defer teardown()
+
+	deleted := false
+	mux.HandleFunc("/session/123", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "DELETE")
+		deleted = true
+	})
+	mux.HandleFunc("/session/123/title", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"status": 0, "value": "title"}`)
+	})
+
+	wd, err := NewRemote(caps, server.URL, KeepSessionOnCancel(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	wd.SetContext(ctx)
+	cancel()
+	if _, err := wd.Title(); err != ErrCanceled {
+		t.Fatalf("Title returned error %v, want %v", err, ErrCanceled)
+	}
+	if deleted {
+		t.Fatal("Session deleted after the driver context was canceled")
+	}
+
+	wd.SetContext(context.Background())
+	if title, err := wd.Title(); err != nil || title != "title" {
+		t.Fatalf("Title after a new context = %q, %v, want %q", title, err, "title")
+	}
+	if err := wd.Quit(); err != nil || !deleted {
+		t.Errorf("Quit returned %v, deleted the session: %t", err, deleted)
+	}
+}
+
+func TestRetryOnNetworkError(t *testing.T) {
+	setup()
+	defer teardown()
+
+	dropConnection := func(w http.ResponseWriter) {
+		conn, _, err := w.(http.Hijacker).Hijack()
+		if err != nil {
+			t.Fatal(err)
+		}
+		conn.Close()
+	}
+	var titleRequests, urlRequests int32
+	mux.HandleFunc("/session/123/title", func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&titleRequests, 1) <= 2 {
+			dropConnection(w)
+			return
+		}
+		fmt.Fprint(w, `{"status": 0, "value": "title"}`)
+	})
+	mux.HandleFunc("/session/123/url", func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&urlRequests, 1)
+		dropConnection(w)
+	})
+
+	wd, err := NewRemote(caps, server.URL, RetryOnNetworkError(3, 10*time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if title, err := wd.Title(); err != nil || title != "title" {
+		t.Fatalf("Title = %q, %v, want %q", title, err, "title")
+	}
+	if n := atomic.LoadInt32(&titleRequests); n != 3 {
+		t.Errorf("Got %d title requests, want 3", n)
+	}
+
+	if err := wd.Get("http://example.com"); err == nil {
+		t.Fatal("Get returned no error for a dropped connection")
+	}
+	if n := atomic.LoadInt32(&urlRequests); n != 1 {
+		t.Errorf("Got %d POST url requests, want 1 (no retries)", n)
+	}
+}
+
+func TestQuit_PerSession(t *testing.T) {
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	sessions := 0
+	mux.HandleFunc("/session", func(w http.ResponseWriter, r *http.Request) {
+		sessions++
+		fmt.Fprintf(w, `{"sessionId": "s%d"}`, sessions)
+	})
+	deleted := make(map[string]int)
+	for _, id := range []string{"s1", "s2"} {
+		id := id
+		mux.HandleFunc("/session/"+id, func(w http.ResponseWriter, r *http.Request) {
+			testMethod(t, r, "DELETE")
+			deleted[id]++
+		})
+	}
+
+	wd, err := NewRemote(caps, server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		if err := wd.Quit(); err != nil {
+			t.Fatalf("Quit returned error: %v", err)
+		}
+	}
+	if _, err := wd.NewSession(); err != nil {
+		t.Fatal(err)
+	}
+	if err := wd.DeleteSession(); err != nil {
+		t.Fatalf("DeleteSession returned error: %v", err)
+	}
+	if want := map[string]int{"s1": 1, "s2": 1}; !reflect.DeepEqual(deleted, want) {
+		t.Errorf("Deleted sessions %v, want %v", deleted, want)
+	}
+}
+
+func TestRestart(t *testing.T) {
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	sessions := 0
+	mux.HandleFunc("/session", func(w http.ResponseWriter, r *http.Request) {
+		sessions++
+		fmt.Fprintf(w, `{"sessionId": "s%d"}`, sessions)
+	})
+	mux.HandleFunc("/session/s1", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "DELETE")
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprint(w, `{"status": 6, "value": {"message": "session already gone"}}`)
+	})
+	mux.HandleFunc("/session/s2/title", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"sessionId": "s2", "status": 0, "value": "restarted"}`)
+	})
+
+	wd, err := NewRemote(caps, server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := wd.Restart(); err != nil {
+		t.Fatalf("Restart returned error: %v", err)
+	}
+	if id := wd.GetSessionID(); id != "s2" {
+		t.Errorf("GetSessionID after restart = %q, want %q", id, "s2")
+	}
+	if title, err := wd.Title(); err != nil || title != "restarted" {
+		t.Errorf("Title after restart = %q, %v, want %q", title, err, "restarted")
+	}
+}
+
+func TestRestart_Tabbed(t *testing.T) {
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	sessions := 0
+	mux.HandleFunc("/session", func(w http.ResponseWriter, r *http.Request) {
+		sessions++
+		fmt.Fprintf(w, `{"value": {"sessionId": "s%d", "capabilities": {}}}`, sessions)
+	})
+	mux.HandleFunc("/session/s1/", func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/session/s1/window":
+			if r.Method == "GET" {
+				fmt.Fprint(w, `{"value": "t1"}`)
+				return
+			}
+		case "/session/s1/window/new":
+			fmt.Fprint(w, `{"value": {"handle": "t2", "type": "tab"}}`)
+			return
+		case "/session/s1/element":
+			fmt.Fprintf(w, `{"value": {"%s": "f1"}}`, webElementKey)
+			return
+		}
+		fmt.Fprint(w, `{"value": null}`)
+	})
+	mux.HandleFunc("/session/s2/", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/session/s2/title" {
+			t.Errorf("Got %s %s after the restart, want only the title", r.Method, r.URL.Path)
+		}
+		fmt.Fprint(w, `{"value": "restarted"}`)
+	})
+
+	wd, err := NewRemote(caps, server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tab, err := wd.NewTab()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := wd.SwitchFrame("f"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tab.Title(); err != nil {
+		t.Fatal(err)
+	}
+	if err := wd.Restart(); err != nil {
+		t.Fatalf("Restart returned error: %v", err)
+	}
+	if title, err := wd.Title(); err != nil || title != "restarted" {
+		t.Errorf("Title after restart = %q, %v, want %q", title, err, "restarted")
+	}
+	if path := wd.CurrentFramePath(); path != nil {
+		t.Errorf("CurrentFramePath after restart = %q, want none", path)
+	}
+}
+
+func TestFindElement_BadReply(t *testing.T) {
+	setup()
+	defer teardown()
+
+	var element, elements string
+	mux.HandleFunc("/session/123/element", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, element)
+	})
+	mux.HandleFunc("/session/123/elements", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, elements)
+	})
+
+	replies := []struct{ element, elements string }{
+		{`{"status": 0, "value": "not an element"}`, `{"status": 0, "value": {"ELEMENT": "1"}}`},
+		{``, ``},
+		{`{"status": 0, "value": null}`, `{"status": 0, "value": null}`},
+		{`{"status": 0, "value": {"id": "1"}}`, `{"status": 0, "value": [{"ELEMENT": "1"}, {"id": "2"}]}`},
+	}
+	for _, reply := range replies {
+		element, elements = reply.element, reply.elements
+		if _, err := client.FindElement(ById, "x"); err == nil {
+			t.Errorf("FindElement returned no error for the reply %q", element)
+		}
+		if _, err := client.FindElements(ById, "x"); err == nil {
+			t.Errorf("FindElements returned no error for the reply %q", elements)
+		}
+	}
+}
+
+func TestNetworkConnection(t *testing.T) {
+	setup()
+	defer teardown()
+
+	connection := 0
+	mux.HandleFunc("/session/123/network_connection", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "POST" {
+			var v struct {
+				Parameters struct {
+					Type int `json:"type"`
+				} `json:"parameters"`
+			}
+			json.NewDecoder(r.Body).Decode(&v)
+			connection = v.Parameters.Type
+		}
+		fmt.Fprintf(w, `{"status": 0, "value": %d}`, connection)
+	})
+
+	if err := client.SetNetworkConnection(WifiConnection); err != nil {
+		t.Fatalf("SetNetworkConnection returned error: %v", err)
+	}
+	got, err := client.NetworkConnection()
+	if err != nil {
+		t.Fatalf("NetworkConnection returned error: %v", err)
+	}
+	if got != WifiConnection {
+		t.Errorf("NetworkConnection = %d, want %d", got, WifiConnection)
+	}
+}
+
+func TestNetworkConnection_NotSupported(t *testing.T) {
+	setup()
+	defer teardown()
+
+	mux.HandleFunc("/session/123/network_connection", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprint(w, `{"value": {"error": "unknown command", "message": "unknown command"}}`)
+	})
+
+	if err := client.SetNetworkConnection(WifiConnection); err != ErrNotSupported {
+		t.Errorf("SetNetworkConnection returned error %v, want %v", err, ErrNotSupported)
+	}
+	if _, err := client.NetworkConnection(); err != ErrNotSupported {
+		t.Errorf("NetworkConnection returned error %v, want %v", err, ErrNotSupported)
+	}
+}
+
+func TestIME(t *testing.T) {
+	setup()
+	defer teardown()
+
+	var got []string
+	record := func(value string) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			got = append(got, r.Method+" "+r.URL.Path)
+			fmt.Fprintf(w, `{"status": 0, "value": %s}`, value)
+		}
+	}
+	mux.HandleFunc("/session/123/ime/available_engines", record(`["engine"]`))
+	mux.HandleFunc("/session/123/ime/active_engine", record(`"engine"`))
+	mux.HandleFunc("/session/123/ime/activated", record(`true`))
+	mux.HandleFunc("/session/123/ime/deactivate", record(`null`))
+	mux.HandleFunc("/session/123/ime/activate", func(w http.ResponseWriter, r *http.Request) {
+		var v map[string]string
+		json.NewDecoder(r.Body).Decode(&v)
+		if v["engine"] != "engine" {
+			t.Errorf("Request body = %+v, want engine %q", v, "engine")
+		}
+		record(`null`)(w, r)
+	})
+
+	if _, err := client.AvailableEngines(); err != nil {
+		t.Errorf("AvailableEngines returned error: %v", err)
+	}
+	if _, err := client.ActiveEngine(); err != nil {
+		t.Errorf("ActiveEngine returned error: %v", err)
+	}
+	if _, err := client.IsEngineActivated(); err != nil {
+		t.Errorf("IsEngineActivated returned error: %v", err)
+	}
+	if err := client.DeactivateEngine(); err != nil {
+		t.Errorf("DeactivateEngine returned error: %v", err)
+	}
+	if err := client.ActivateEngine("engine"); err != nil {
+		t.Errorf("ActivateEngine returned error: %v", err)
+	}
+
+	want := []string{
+		"GET /session/123/ime/available_engines",
+		"GET /session/123/ime/active_engine",
+		"GET /session/123/ime/activated",
+		"POST /session/123/ime/deactivate",
+		"POST /session/123/ime/activate",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Requests = %q, want %q", got, want)
+	}
+}
+
+func TestGetCookies_Expiry(t *testing.T) {
+	setup()
+	defer teardown()
+
+	mux.HandleFunc("/session/123/cookie", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"status": 0, "value": [
+			{"name": "session", "value": "a"},
+			{"name": "persistent", "value": "b", "expiry": 1700000000},
+			{"name": "null", "value": "c", "expiry": null}
+		]}`)
+	})
+
+	cookies, err := client.GetCookies()
+	if err != nil {
+		t.Fatalf("GetCookies returned error: %v", err)
+	}
+	want := []Cookie{
+		{Name: "session", Value: "a"},
+		{Name: "persistent", Value: "b", Expiry: 1700000000},
+		{Name: "null", Value: "c"},
+	}
+	if !reflect.DeepEqual(cookies, want) {
+		t.Errorf("GetCookies returned %+v, want %+v", cookies, want)
+	}
+}
+
+func TestAddCookie_Expires(t *testing.T) {
+	setup()
+	defer teardown()
+
+	var got struct {
+		Cookie map[string]interface{} `json:"cookie"`
+	}
+	mux.HandleFunc("/session/123/cookie", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "POST")
+		json.NewDecoder(r.Body).Decode(&got)
+		fmt.Fprint(w, `{"status": 0}`)
+	})
+
+	expires := time.Unix(1700000000, 0)
+	if err := client.AddCookie(&Cookie{Name: "a", Value: "1", Expires: expires}); err != nil {
+		t.Fatalf("AddCookie returned error: %v", err)
+	}
+	if got.Cookie["expiry"] != float64(1700000000) {
+		t.Errorf("AddCookie sent expiry %v, want %d", got.Cookie["expiry"], 1700000000)
+	}
+
+	c := Cookie{Expiry: 1700000000}
+	if !c.ExpiresAt().Equal(expires) {
+		t.Errorf("ExpiresAt returned %v, want %v", c.ExpiresAt(), expires)
+	}
+}
+
+func TestAddHTTPCookie(t *testing.T) {
+	setup()
+	defer teardown()
+
+	var got struct {
+		Cookie map[string]interface{} `json:"cookie"`
+	}
+	mux.HandleFunc("/session/123/cookie", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "POST")
+		json.NewDecoder(r.Body).Decode(&got)
+		fmt.Fprint(w, `{"status": 0}`)
+	})
+
+	cookie := &http.Cookie{
+		Name:     "session",
+		Value:    "s3cr3t",
+		Domain:   "example.com",
+		Secure:   true,
+		HttpOnly: true,
+		Expires:  time.Unix(1700000000, 0),
+		SameSite: http.SameSiteStrictMode,
+	}
+	if err := client.AddHTTPCookie(cookie); err != nil {
+		t.Fatalf("AddHTTPCookie returned error: %v", err)
+	}
+	want := map[string]interface{}{
+		"name":     "session",
+		"value":    "s3cr3t",
+		"path":     "/",
+		"domain":   "example.com",
+		"secure":   true,
+		"httpOnly": true,
+		"sameSite": "Strict",
+		"expiry":   float64(1700000000),
+	}
+	if !reflect.DeepEqual(got.Cookie, want) {
+		t.Errorf("AddHTTPCookie sent %v, want %v", got.Cookie, want)
+	}
+}
+
+func TestExportImportCookies(t *testing.T) {
+	setup()
+	defer teardown()
+
+	var added []map[string]interface{}
+	mux.HandleFunc("/session/123/cookie", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "GET" {
+			fmt.Fprint(w, `{"status": 0, "value": [
+				{"name": "session", "value": "s3cr3t", "path": "/", "domain": "example.com", "secure": true, "httpOnly": true, "expiry": 1700000000},
+				{"name": "theme", "value": "dark", "path": "/", "domain": "example.com", "secure": false, "httpOnly": false}
+			]}`)
+			return
+		}
+		var v struct {
+			Cookie map[string]interface{} `json:"cookie"`
+		}
+		json.NewDecoder(r.Body).Decode(&v)
+		added = append(added, v.Cookie)
+		fmt.Fprint(w, `{"status": 0}`)
+	})
+
+	data, err := client.ExportCookies()
+	if err != nil {
+		t.Fatalf("ExportCookies returned error: %v", err)
+	}
+	if err := client.ImportCookies(data); err != nil {
+		t.Fatalf("ImportCookies returned error: %v", err)
+	}
+	want := []map[string]interface{}{
+		{"name": "session", "value": "s3cr3t", "path": "/", "domain": "example.com", "secure": true, "httpOnly": true, "expiry": float64(1700000000)},
+		{"name": "theme", "value": "dark", "path": "/", "domain": "example.com", "secure": false, "httpOnly": false},
+	}
+	if !reflect.DeepEqual(added, want) {
+		t.Errorf("ImportCookies added %v, want %v", added, want)
+	}
+}
+
+func TestParseCookieExpiry_CountMismatch(t *testing.T) {
+	cookies := []Cookie{{Name: "a"}, {Name: "b"}}
+	parseCookieExpiry(&cookies, json.RawMessage(`[{"expiry": 10}]`))
+	if cookies[0].Expiry != 10 || cookies[1].Expiry != 0 {
+		t.Errorf("parseCookieExpiry set %+v", cookies)
+	}
+}
+
+func TestGetNamedCookie(t *testing.T) {
+	setupW3C()
+	defer teardown()
+
+	mux.HandleFunc("/session/123/cookie/", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		if r.URL.Path != "/session/123/cookie/a" {
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"value": {"error": "no such cookie", "message": "no such cookie"}}`)
+			return
+		}
+		fmt.Fprint(w, `{"value": {"name": "a", "value": "1", "expiry": 1700000000}}`)
+	})
+
+	c, err := client.GetNamedCookie("a")
+	if err != nil {
+		t.Fatalf("GetNamedCookie returned error: %v", err)
+	}
+	if want := (Cookie{Name: "a", Value: "1", Expiry: 1700000000}); *c != want {
+		t.Errorf("GetNamedCookie returned %+v, want %+v", *c, want)
+	}
+
+	if _, err := client.GetNamedCookie("b"); err != ErrNoSuchCookie {
+		t.Errorf("GetNamedCookie returned error %v, want %v", err, ErrNoSuchCookie)
+	}
+}
+
+func TestGetNamedCookie_Escaped(t *testing.T) {
+	setupW3C()
+	defer teardown()
+
+	mux.HandleFunc("/session/123/cookie/", func(w http.ResponseWriter, r *http.Request) {
+		if want := "/session/123/cookie/a%20b%2Fc%3F%25"; r.URL.EscapedPath() != want {
+			t.Errorf("GetNamedCookie requested %s, want %s", r.URL.EscapedPath(), want)
+		}
+		fmt.Fprint(w, `{"value": {"name": "a b/c?%", "value": "1"}}`)
+	})
+
+	if _, err := client.GetNamedCookie("a b/c?%"); err != nil {
+		t.Fatalf("GetNamedCookie returned error: %v", err)
+	}
+}
+
+func TestGetNamedCookie_JSONWire(t *testing.T) {
+	setup()
+	defer teardown()
+
+	mux.HandleFunc("/session/123/cookie", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"status": 0, "value": [{"name": "a", "value": "1"}, {"name": "b", "value": "2"}]}`)
+	})
+
+	c, err := client.GetNamedCookie("b")
+	if err != nil {
+		t.Fatalf("GetNamedCookie returned error: %v", err)
+	}
+	if c.Value != "2" {
+		t.Errorf("GetNamedCookie returned %+v, want value %q", *c, "2")
+	}
+	if _, err := client.GetNamedCookie("c"); err != ErrNoSuchCookie {
+		t.Errorf("GetNamedCookie returned error %v, want %v", err, ErrNoSuchCookie)
+	}
+}
+
+func TestErrorCode(t *testing.T) {
+	setupW3C()
+	defer teardown()
+
+	mux.HandleFunc("/session/123/element", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprint(w, `{"value": {"error": "no such element", "message": "Unable to locate element"}}`)
+	})
+
+	_, err := client.FindElement(ById, "missing")
+	if code, ok := ErrorCode(err); !ok || code != 7 {
+		t.Errorf("ErrorCode(%v) = %d, %v, want 7, true", err, code, ok)
+	}
+
+	_, err = NewRemote(nil, "http://127.0.0.1:1/wd/hub")
+	if code, ok := ErrorCode(err); err == nil || ok {
+		t.Errorf("ErrorCode(%v) = %d, %v, want false", err, code, ok)
+	}
+}
+
+func TestReplyError(t *testing.T) {
+	tests := []struct {
+		reply string
+		want  Error
+	}{
+		{
+			`{"status": 7, "value": {"message": "{\"errorMessage\": \"Unable to find element\"}"}}`,
+			Error{Code: 7, Message: "no such element", Details: "Unable to find element"},
+		},
+		{
+			`{"status": 13, "value": {"message": "chrome not reachable"}}`,
+			Error{Code: 13, Message: "unknown error", Details: "chrome not reachable"},
+		},
+		{
+			`{"status": 99, "value": null}`,
+			Error{Code: 99, Message: "unknown error - 99"},
+		},
+		{
+			`{"value": {"error": "stale element reference", "message": "element is not attached"}}`,
+			Error{Code: 10, Message: "stale element reference", Details: "element is not attached"},
+		},
+		{
+			`{"value": {"error": "no such shadow root", "message": "no shadow root"}}`,
+			Error{Code: 0, Message: "no such shadow root", Details: "no shadow root"},
+		},
+	}
+	for _, test := range tests {
+		var r reply
+		if err := json.Unmarshal([]byte(test.reply), &r); err != nil {
+			t.Fatal(err)
+		}
+		if got := replyError(&r); *got != test.want {
+			t.Errorf("replyError(%s) = %+v, want %+v", test.reply, *got, test.want)
+		}
+	}
+}
+
+func TestError_As(t *testing.T) {
+	setupW3C()
+	defer teardown()
+
+	mux.HandleFunc("/session/123/element", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprint(w, `{"value": {"error": "no such element", "message": "Unable to locate element"}}`)
+	})
+
+	_, err := client.FindElement(ByCSSSelector, "#missing")
+	var e *Error
+	if !errors.As(err, &e) {
+		t.Fatalf("FindElement returned %T, want *Error", err)
+	}
+	if e.Code != 7 || e.Details != "Unable to locate element" {
+		t.Errorf("FindElement returned %+v, want code 7 with the server's message", e)
+	}
+	if !errors.Is(err, ErrNoSuchElement) {
+		t.Errorf("errors.Is(%v, ErrNoSuchElement) = false, want true", err)
+	}
+}
+
+func TestNewWindow(t *testing.T) {
+	setupW3C()
+	defer teardown()
+
+	var got map[string]string
+	mux.HandleFunc("/session/123/window/new", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "POST")
+		json.NewDecoder(r.Body).Decode(&got)
+		fmt.Fprint(w, `{"value": {"handle": "w2", "type": "tab"}}`)
+	})
+
+	handle, err := client.NewWindow("")
+	if err != nil {
+		t.Fatalf("NewWindow returned error: %v", err)
+	}
+	if handle != "w2" {
+		t.Errorf("NewWindow returned %q, want %q", handle, "w2")
+	}
+	if got["type"] != "tab" {
+		t.Errorf("NewWindow sent type %q, want %q", got["type"], "tab")
+	}
+}
+
+func TestWaitForStableFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "selenium")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "download.bin")
+
+	done := make(chan struct{}, 1)
+	go func() {
+		f, err := os.Create(path)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		defer f.Close()
+		for i := 0; i < 5; i++ {
+			f.Write(make([]byte, 1024))
+			time.Sleep(100 * time.Millisecond)
+		}
+		done <- struct{}{}
+	}()
+
+	if err := WaitForStableFile(path, 300*time.Millisecond, 5*time.Second); err != nil {
+		t.Fatalf("WaitForStableFile returned error: %v", err)
+	}
+	select {
+	case <-done:
+	default:
+		t.Fatal("WaitForStableFile returned while the file was being written")
+	}
+	if fi, err := os.Stat(path); err != nil || fi.Size() != 5*1024 {
+		t.Errorf("file is %v, want %d bytes", fi, 5*1024)
+	}
+}
+
+func TestWaitForStableFile_Timeout(t *testing.T) {
+	if err := WaitForStableFile("/nonexistent/download.bin", 0, 100*time.Millisecond); err == nil {
+		t.Error("WaitForStableFile returned nil for a missing file")
+	}
+}
+
+func TestSwitchWindow_W3C(t *testing.T) {
+	setupW3C()
+	defer teardown()
+
+	var got map[string]string
+	mux.HandleFunc("/session/123/window", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "GET" {
+			fmt.Fprint(w, `{"value": "w1"}`)
+			return
+		}
+		testMethod(t, r, "POST")
+		json.NewDecoder(r.Body).Decode(&got)
+		fmt.Fprint(w, `{"value": null}`)
+	})
+	mux.HandleFunc("/session/123/window/handles", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		fmt.Fprint(w, `{"value": ["w1", "w2"]}`)
+	})
+
+	if handle, err := client.CurrentWindowHandle(); err != nil || handle != "w1" {
+		t.Errorf("CurrentWindowHandle returned %q, %v, want %q", handle, err, "w1")
+	}
+	if handles, err := client.WindowHandles(); err != nil || !reflect.DeepEqual(handles, []string{"w1", "w2"}) {
+		t.Errorf("WindowHandles returned %v, %v, want [w1 w2]", handles, err)
+	}
+	if err := client.SwitchWindow("w2"); err != nil {
+		t.Fatalf("SwitchWindow returned error: %v", err)
+	}
+	if want := map[string]string{"handle": "w2"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("SwitchWindow sent %v, want %v", got, want)
+	}
+}
+
+func TestSwitchFrame(t *testing.T) {
+	setupW3C()
+	defer teardown()
+
+	var got []map[string]interface{}
+	mux.HandleFunc("/session/123/frame", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "POST")
+		var params map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&params)
+		got = append(got, params)
+		fmt.Fprint(w, `{"value": null}`)
+	})
+	var selector string
+	mux.HandleFunc("/session/123/element", func(w http.ResponseWriter, r *http.Request) {
+		var params map[string]string
+		json.NewDecoder(r.Body).Decode(&params)
+		selector = params["value"]
+		fmt.Fprintf(w, `{"value": {"%s": "f1"}}`, webElementKey)
+	})
+
+	frames := []interface{}{`say "hi"`, 1, &remoteWE{client.(*remoteWebDriver), "e1"}, nil}
+	for _, frame := range frames {
+		if err := client.SwitchFrame(frame); err != nil {
+			t.Fatalf("SwitchFrame(%v) returned error: %v", frame, err)
+		}
+	}
+	if err := client.SwitchToDefaultContent(); err != nil {
+		t.Fatalf("SwitchToDefaultContent returned error: %v", err)
+	}
+	want := []map[string]interface{}{
+		{"id": map[string]interface{}{"ELEMENT": "f1", webElementKey: "f1"}},
+		{"id": float64(1)},
+		{"id": map[string]interface{}{"ELEMENT": "e1", webElementKey: "e1"}},
+		{"id": nil},
+		{"id": nil},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("SwitchFrame sent %v, want %v", got, want)
+	}
+	wantSelector := `frame[id="say \"hi\""],iframe[id="say \"hi\""],frame[name="say \"hi\""],iframe[name="say \"hi\""]`
+	if selector != wantSelector {
+		t.Errorf("SwitchFrame looked up the frame with %q, want %q", selector, wantSelector)
+	}
+
+	if err := client.SwitchFrame(1.5); err == nil {
+		t.Error("SwitchFrame(1.5) returned no error")
+	}
+}
+
+func TestSwitchFrame_JSONWire(t *testing.T) {
+	setup()
+	defer teardown()
+
+	var got map[string]interface{}
+	mux.HandleFunc("/session/123/frame", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "POST")
+		json.NewDecoder(r.Body).Decode(&got)
+		fmt.Fprint(w, `{"status": 0}`)
+	})
+
+	if err := client.SwitchFrame("name"); err != nil {
+		t.Fatalf("SwitchFrame returned error: %v", err)
+	}
+	if want := map[string]interface{}{"id": "name"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("SwitchFrame sent %v, want %v", got, want)
+	}
+}
+
+func TestPrintPage(t *testing.T) {
+	setupW3C()
+	defer teardown()
+
+	var got map[string]interface{}
+	mux.HandleFunc("/session/123/print", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "POST")
+		json.NewDecoder(r.Body).Decode(&got)
+		fmt.Fprintf(w, `{"value": %q}`, base64.StdEncoding.EncodeToString([]byte("%PDF-1.4")))
+	})
+
+	pdf, err := client.PrintPage(PrintOptions{
+		Orientation: "landscape",
+		PageWidth:   10,
+		Margin:      &PrintMargin{},
+	})
+	if err != nil {
+		t.Fatalf("PrintPage returned error: %v", err)
+	}
+	data, err := ioutil.ReadAll(pdf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "%PDF-1.4" {
+		t.Errorf("PrintPage returned %q, want %q", data, "%PDF-1.4")
+	}
+	want := map[string]interface{}{
+		"orientation": "landscape",
+		"page":        map[string]interface{}{"width": float64(10)},
+		"margin": map[string]interface{}{
+			"top": float64(0), "bottom": float64(0), "left": float64(0), "right": float64(0),
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("PrintPage sent %v, want %v", got, want)
+	}
+}
+
+func TestExecuteCDPCommand(t *testing.T) {
+	setupSession(`{"value": {"sessionId": "123", "capabilities": {"browserName": "chrome"}}}`)
+	defer teardown()
+
+	var got map[string]interface{}
+	mux.HandleFunc("/session/123/chromium/send_command_and_get_result", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "POST")
+		json.NewDecoder(r.Body).Decode(&got)
+		fmt.Fprint(w, `{"value": {"result": {"value": 2}}}`)
+	})
+
+	res, err := client.ExecuteCDPCommand("Runtime.evaluate", map[string]interface{}{"expression": "1+1"})
+	if err != nil {
+		t.Fatalf("ExecuteCDPCommand returned error: %v", err)
+	}
+	want := map[string]interface{}{"result": map[string]interface{}{"value": float64(2)}}
+	if !reflect.DeepEqual(res, want) {
+		t.Errorf("ExecuteCDPCommand returned %v, want %v", res, want)
+	}
+	sent := map[string]interface{}{
+		"cmd":    "Runtime.evaluate",
+		"params": map[string]interface{}{"expression": "1+1"},
+	}
+	if !reflect.DeepEqual(got, sent) {
+		t.Errorf("ExecuteCDPCommand sent %v, want %v", got, sent)
+	}
+}
+
+func TestSetLocation_Permission(t *testing.T) {
+	for _, tc := range []struct {
+		origin string
+		opts   []LocationOption
+		grant  bool
+	}{
+		{origin: "http://example.com", grant: true},
+		{origin: "null"},
+		{origin: "http://example.com", opts: []LocationOption{GrantLocationPermission(false)}},
+	} {
+		setupSession(`{"value": {"sessionId": "123", "capabilities": {"browserName": "chrome"}}}`)
+
+		var scripts int
+		mux.HandleFunc("/session/123/execute", func(w http.ResponseWriter, r *http.Request) {
+			scripts++
+			fmt.Fprintf(w, `{"value": %q}`, tc.origin)
+		})
+		var cmds []map[string]interface{}
+		mux.HandleFunc("/session/123/chromium/send_command_and_get_result", func(w http.ResponseWriter, r *http.Request) {
+			var v map[string]interface{}
+			json.NewDecoder(r.Body).Decode(&v)
+			cmds = append(cmds, v)
+			fmt.Fprint(w, `{"value": {}}`)
+		})
+
+		if err := client.SetLocation(Location{Latitude: 1, Longitude: 2}, tc.opts...); err != nil {
+			t.Fatalf("SetLocation on %s returned error: %v", tc.origin, err)
+		}
+		var want []string
+		if tc.grant {
+			want = append(want, "Browser.grantPermissions")
+		}
+		want = append(want, "Emulation.setGeolocationOverride")
+		var got []string
+		for _, cmd := range cmds {
+			got = append(got, cmd["cmd"].(string))
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("SetLocation on %s with %d options sent %v, want %v", tc.origin, len(tc.opts), got, want)
+		}
+		if tc.grant {
+			params := cmds[0]["params"].(map[string]interface{})
+			if params["origin"] != tc.origin {
+				t.Errorf("Permission granted to %v, want %s", params["origin"], tc.origin)
+			}
+		}
+		if len(tc.opts) > 0 && scripts != 0 {
+			t.Error("SetLocation read the origin without granting the permission")
+		}
+		teardown()
+	}
+}
+
+func TestNetworkConditions(t *testing.T) {
+	setupSession(`{"value": {"sessionId": "123", "capabilities": {"browserName": "chrome"}}}`)
+	defer teardown()
+
+	var set map[string]NetworkConditions
+	mux.HandleFunc("/session/123/chromium/network_conditions", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "POST" {
+			json.NewDecoder(r.Body).Decode(&set)
+			fmt.Fprint(w, `{"value": null}`)
+			return
+		}
+		fmt.Fprint(w, `{"value": {"offline": false, "latency": 400, "download_throughput": 50000, "upload_throughput": 20000}}`)
+	})
+
+	slow3G := NetworkConditions{Latency: 400, DownloadThroughput: 50000, UploadThroughput: 20000}
+	if err := client.SetNetworkConditions(slow3G); err != nil {
+		t.Fatalf("SetNetworkConditions returned error: %v", err)
+	}
+	if set["network_conditions"] != slow3G {
+		t.Errorf("SetNetworkConditions sent %+v, want %+v", set["network_conditions"], slow3G)
+	}
+	conditions, err := client.GetNetworkConditions()
+	if err != nil {
+		t.Fatalf("GetNetworkConditions returned error: %v", err)
+	}
+	if *conditions != slow3G {
+		t.Errorf("GetNetworkConditions returned %+v, want %+v", *conditions, slow3G)
+	}
+}
+
+func TestWithCommandTimeout(t *testing.T) {
+	setup()
+	defer teardown()
+
+	mux.HandleFunc("/session/123/execute", func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(300 * time.Millisecond)
+		fmt.Fprint(w, `{"status": 0, "value": "done"}`)
+	})
+
+	wd, err := NewRemote(caps, server.URL, WithCommandTimeout(2*time.Second))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := wd.ExecuteScript("return 'done';", nil); err != nil {
+		t.Errorf("ExecuteScript returned error under a raised timeout: %v", err)
+	}
+
+	wd, err = NewRemote(caps, server.URL, WithCommandTimeout(50*time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := wd.ExecuteScript("return 'done';", nil); err == nil {
+		t.Error("ExecuteScript returned no error under a short timeout")
+	}
+}
+
+func TestCapabilitiesBuilder(t *testing.T) {
+	caps := NewCapabilities().
+		Browser("chrome").
+		Platform("LINUX").
+		AcceptInsecureCerts(true).
+		PageLoadStrategy("eager").
+		Proxy(Proxy{Type: "manual", HTTPProxy: "proxy:8080", NoProxy: []string{"localhost"}}).
+		Logging(LoggingPrefs{"browser": LogAll})
+
+	want := Capabilities{
+		"browserName":         "chrome",
+		"platform":            "LINUX",
+		"acceptInsecureCerts": true,
+		"pageLoadStrategy":    "eager",
+		"proxy": map[string]interface{}{
+			"proxyType": "manual",
+			"httpProxy": "proxy:8080",
+			"noProxy":   []string{"localhost"},
+		},
+		"loggingPrefs": map[string]string{"browser": "ALL"},
+	}
+	if !reflect.DeepEqual(caps, want) {
+		t.Errorf("Capabilities are %v, want %v", caps, want)
+	}
+}
+
+func TestCapabilitiesBuilder_PageLoadStrategy(t *testing.T) {
+	for _, strategy := range []string{PageLoadNormal, PageLoadEager, PageLoadNone} {
+		caps := NewCapabilities().PageLoadStrategy(strategy)
+		if got := caps["pageLoadStrategy"]; got != strategy {
+			t.Errorf("PageLoadStrategy(%q) set %v", strategy, got)
+		}
+		if got := caps.GetPageLoadStrategy(); got != strategy {
+			t.Errorf("GetPageLoadStrategy after PageLoadStrategy(%q) = %q", strategy, got)
+		}
+	}
+}
+
+func TestGetPageLoadStrategy(t *testing.T) {
+	setup()
+	defer teardown()
+
+	mux.HandleFunc("/session/123", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		fmt.Fprint(w, `{"status": 0, "value": {"browserName": "chrome", "pageLoadStrategy": "eager"}}`)
+	})
+
+	if got := NewCapabilities().GetPageLoadStrategy(); got != "" {
+		t.Errorf("GetPageLoadStrategy of empty capabilities = %q", got)
+	}
+	caps, err := client.Capabilities()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := caps.GetPageLoadStrategy(); got != PageLoadEager {
+		t.Errorf("GetPageLoadStrategy = %q, want %q", got, PageLoadEager)
+	}
+}
+
+func TestSetProxy(t *testing.T) {
+	caps := NewCapabilities()
+	err := caps.SetProxy(Proxy{Type: ProxyManual, SOCKSProxy: "socks:1080", SOCKSVersion: 5})
+	if err != nil {
+		t.Fatalf("SetProxy returned error: %v", err)
+	}
+	want := map[string]interface{}{"proxyType": "manual", "socksProxy": "socks:1080", "socksVersion": 5}
+	if !reflect.DeepEqual(caps["proxy"], want) {
+		t.Errorf("SetProxy set %v, want %v", caps["proxy"], want)
+	}
+
+	for _, p := range []Proxy{
+		{Type: ProxyManual},
+		{Type: ProxyManual, SOCKSProxy: "socks:1080"},
+		{Type: ProxyPAC},
+		{Type: "bogus"},
+	} {
+		if err := NewCapabilities().SetProxy(p); err == nil {
+			t.Errorf("SetProxy(%+v) returned no error", p)
+		}
+	}
+}
+
+func TestSetChromeOptions(t *testing.T) {
+	opts := new(ChromeOptions).
+		AddArgument("--headless").
+		SetBinary("/usr/bin/chromium").
+		AddExtension([]byte("crx")).
+		SetPrefs(map[string]interface{}{"download.default_directory": "/tmp"})
+	data, err := json.Marshal(NewCapabilities().SetChromeOptions(opts))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"goog:chromeOptions":{"args":["--headless"],"binary":"/usr/bin/chromium","extensions":["Y3J4"],"prefs":{"download.default_directory":"/tmp"}}}`
+	if string(data) != want {
+		t.Errorf("Capabilities marshal to %s, want %s", data, want)
+	}
+}
+
+func TestSetFirefoxOptions(t *testing.T) {
+	opts := new(FirefoxOptions).AddArgument("-headless")
+	data, err := json.Marshal(NewCapabilities().SetFirefoxOptions(opts))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"moz:firefoxOptions":{"args":["-headless"]}}`; string(data) != want {
+		t.Errorf("Capabilities marshal to %s, want %s", data, want)
+	}
+}
+
+func TestHeadless(t *testing.T) {
+	caps := HeadlessChrome()
+	caps.ChromeOptions().AddArgument("--window-size=1280,1024")
+	data, err := json.Marshal(caps)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"browserName":"chrome","goog:chromeOptions":{"args":["--headless","--disable-gpu","--window-size=1280,1024"]}}`
+	if string(data) != want {
+		t.Errorf("HeadlessChrome marshals to %s, want %s", data, want)
+	}
+
+	if data, err = json.Marshal(HeadlessFirefox()); err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"browserName":"firefox","moz:firefoxOptions":{"args":["-headless"]}}`; string(data) != want {
+		t.Errorf("HeadlessFirefox marshals to %s, want %s", data, want)
+	}
+}
+
+func TestBrowserOptions_FromJSON(t *testing.T) {
+	var caps Capabilities
+	err := json.Unmarshal([]byte(`{
+		"goog:chromeOptions": {"args": ["--headless"], "mobileEmulation": {"deviceName": "Pixel 2"}},
+		"moz:firefoxOptions": {"prefs": {"dom.ipc.processCount": 1}, "log": {"level": "trace"}}
+	}`), &caps)
+	if err != nil {
+		t.Fatal(err)
+	}
+	caps.ChromeOptions().AddArgument("--disable-gpu")
+	caps.FirefoxOptions().AddArgument("-headless")
+
+	data, err := json.Marshal(caps)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"goog:chromeOptions":{"args":["--headless","--disable-gpu"],"mobileEmulation":{"deviceName":"Pixel 2"}},` +
+		`"moz:firefoxOptions":{"args":["-headless"],"log":{"level":"trace"},"prefs":{"dom.ipc.processCount":1}}}`
+	if string(data) != want {
+		t.Errorf("Capabilities marshal to %s, want %s", data, want)
+	}
+}
+
+func TestStatus_W3C(t *testing.T) {
+	setupW3C()
+	defer teardown()
+
+	mux.HandleFunc("/status", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"value": {"ready": true, "message": "ready to create a session"}}`)
+	})
+
+	status, err := client.Status()
+	if err != nil {
+		t.Fatalf("Status returned error: %v", err)
+	}
+	if !status.Ready || status.Message != "ready to create a session" {
+		t.Errorf("Status returned %+v", status)
+	}
+}
+
+func TestShadowRoot_Find(t *testing.T) {
+	setupW3C()
+	defer teardown()
+
+	mux.HandleFunc("/session/123/element/e1/shadow", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		fmt.Fprint(w, `{"value": {"shadow-6066-11e4-a52e-4f735466cecf": "s1"}}`)
+	})
+	mux.HandleFunc("/session/123/shadow/s1/elements", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "POST")
+		fmt.Fprint(w, `{"value": [{"element-6066-11e4-a52e-4f735466cecf": "e2"}]}`)
+	})
+
+	host := &remoteWE{client.(*remoteWebDriver), "e1"}
+	root, err := host.ShadowRoot()
+	if err != nil {
+		t.Fatalf("ShadowRoot returned error: %v", err)
+	}
+	elems, err := root.QAll(".inner")
+	if err != nil {
+		t.Fatalf("QAll returned error: %v", err)
+	}
+	if len(elems) != 1 || elems[0].(*remoteWE).id != "e2" {
+		t.Errorf("QAll returned %v, want element e2", elems)
+	}
+}
+
+func TestSendKeyChord(t *testing.T) {
+	setupW3C()
+	defer teardown()
+
+	var got struct {
+		Actions []struct {
+			Actions []map[string]interface{} `json:"actions"`
+		} `json:"actions"`
+	}
+	mux.HandleFunc("/session/123/actions", func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&got)
+		fmt.Fprint(w, `{"value": null}`)
+	})
+
+	if err := client.SendKeyChord(ControlKey, "a"); err != nil {
+		t.Fatalf("SendKeyChord returned error: %v", err)
+	}
+	want := []map[string]interface{}{
+		{"type": "keyDown", "value": ControlKey},
+		{"type": "keyDown", "value": "a"},
+		{"type": "keyUp", "value": "a"},
+		{"type": "keyUp", "value": ControlKey},
+	}
+	if len(got.Actions) != 1 || !reflect.DeepEqual(got.Actions[0].Actions, want) {
+		t.Errorf("SendKeyChord sent %v, want %v", got.Actions, want)
+	}
+}
+
+func TestSendKeyChord_JSONWire(t *testing.T) {
+	setup()
+	defer teardown()
+
+	var got map[string][]string
+	mux.HandleFunc("/session/123/keys", func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&got)
+		fmt.Fprint(w, `{"status": 0}`)
+	})
+
+	if err := client.SendKeyChord(ControlKey, "a"); err != nil {
+		t.Fatalf("SendKeyChord returned error: %v", err)
+	}
+	if want := []string{ControlKey, "a", NullKey}; !reflect.DeepEqual(got["value"], want) {
+		t.Errorf("SendKeyChord sent %q, want %q", got["value"], want)
+	}
+}
+
+func TestIsElementPresent(t *testing.T) {
+	setupW3C()
+	defer teardown()
+
+	mux.HandleFunc("/session/123/element", func(w http.ResponseWriter, r *http.Request) {
+		var params map[string]string
+		json.NewDecoder(r.Body).Decode(&params)
+		switch params["value"] {
+		case "banner":
+			fmt.Fprint(w, `{"value": {"element-6066-11e4-a52e-4f735466cecf": "e1"}}`)
+		case "missing":
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"value": {"error": "no such element", "message": "Unable to locate element"}}`)
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, `{"value": {"error": "invalid selector", "message": "Bad selector"}}`)
+		}
+	})
+
+	for value, want := range map[string]bool{"banner": true, "missing": false} {
+		present, err := client.IsElementPresent(ById, value)
+		if err != nil || present != want {
+			t.Errorf("IsElementPresent(%q) returned %v, %v, want %v, nil", value, present, err, want)
+		}
+	}
+	if _, err := client.IsElementPresent(ByXPATH, "//["); err == nil {
+		t.Error("IsElementPresent returned no error for an invalid selector")
+	}
+}
+
+func TestFindElements_NotFound(t *testing.T) {
+	setup()
+	defer teardown()
+
+	mux.HandleFunc("/session/123/elements", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprint(w, `{"status": 7, "value": {"message": "Unable to locate element"}}`)
+	})
+
+	elems, err := client.FindElements(ByClassName, "no-such-class")
+	if err != nil {
+		t.Fatalf("FindElements returned error: %v", err)
+	}
+	if elems == nil || len(elems) != 0 {
+		t.Errorf("FindElements returned %#v, want an empty slice", elems)
+	}
+}
+
+func TestNormalizedText(t *testing.T) {
+	setup()
+	defer teardown()
+
+	mux.HandleFunc("/session/123/element/e1/text", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"status": 0, "value": "  Total:\n\t 42  items "}`)
+	})
+
+	elem := &remoteWE{client.(*remoteWebDriver), "e1"}
+	text, err := elem.NormalizedText()
+	if err != nil {
+		t.Fatalf("NormalizedText returned error: %v", err)
+	}
+	if want := "Total: 42 items"; text != want {
+		t.Errorf("NormalizedText returned %q, want %q", text, want)
+	}
+}
+
+func TestInnerHTML(t *testing.T) {
+	setupW3C()
+	defer teardown()
+
+	mux.HandleFunc("/session/123/element/e1/property/innerHTML", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		fmt.Fprint(w, `{"value": "<b>bold</b>"}`)
+	})
+
+	elem := &remoteWE{client.(*remoteWebDriver), "e1"}
+	html, err := elem.InnerHTML()
+	if err != nil {
+		t.Fatalf("InnerHTML returned error: %v", err)
+	}
+	if want := "<b>bold</b>"; html != want {
+		t.Errorf("InnerHTML returned %q, want %q", html, want)
+	}
+}
+
+func TestOuterHTML_ScriptFallback(t *testing.T) {
+	setup()
+	defer teardown()
+
+	mux.HandleFunc("/session/123/element/e1/property/outerHTML", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprint(w, `{"status": 9, "value": {"message": "Unknown command"}}`)
+	})
+	mux.HandleFunc("/session/123/execute", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"status": 0, "value": "<p><b>bold</b></p>"}`)
+	})
+
+	elem := &remoteWE{client.(*remoteWebDriver), "e1"}
+	html, err := elem.OuterHTML()
+	if err != nil {
+		t.Fatalf("OuterHTML returned error: %v", err)
+	}
+	if want := "<p><b>bold</b></p>"; html != want {
+		t.Errorf("OuterHTML returned %q, want %q", html, want)
+	}
+}
+
+type recordingLogger struct {
+	lines []string
+}
+
+func (l *recordingLogger) Printf(format string, args ...interface{}) {
+	l.lines = append(l.lines, fmt.Sprintf(format, args...))
+}
+
+func TestWithLogger(t *testing.T) {
+	setup()
+	defer teardown()
+
+	mux.HandleFunc("/session/123/url", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"status": 0, "value": "http://example.com/"}`)
+	})
+
+	logger := new(recordingLogger)
+	wd, err := NewRemote(caps, server.URL, WithLogger(logger))
+	if err != nil {
+		t.Fatal(err)
+	}
+	logger.lines = nil
+	if _, err := wd.CurrentURL(); err != nil {
+		t.Fatal(err)
+	}
+	if len(logger.lines) != 2 || !strings.Contains(logger.lines[0], "/session/123/url") {
+		t.Errorf("Logged %q, want the request and the response", logger.lines)
+	}
+}
+
+func TestWithMetricsHook(t *testing.T) {
+	setup()
+	defer teardown()
+
+	mux.HandleFunc("/session/123/url", func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(10 * time.Millisecond)
+		fmt.Fprint(w, `{"status": 0, "value": "http://example.com/"}`)
+	})
+
+	type call struct {
+		method, url string
+		status      int
+		duration    time.Duration
+		err         error
+	}
+	var calls []call
+	wd, err := NewRemote(caps, server.URL, WithMetricsHook(func(method, url string, status int, duration time.Duration, err error) {
+		calls = append(calls, call{method, url, status, duration, err})
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	calls = nil
+	if _, err := wd.CurrentURL(); err != nil {
+		t.Fatal(err)
+	}
+	if len(calls) != 1 {
+		t.Fatalf("Hook called %d times, want 1", len(calls))
+	}
+	c := calls[0]
+	if c.method != "GET" || c.url != server.URL+"/session/123/url" || c.status != 200 || c.err != nil {
+		t.Errorf("Hook called with %+v", c)
+	}
+	if c.duration < 10*time.Millisecond {
+		t.Errorf("Hook reported duration %s, want at least 10ms", c.duration)
+	}
+}
+
+type recordingTracer struct {
+	spans []string
+	errs  []error
+	// cancel makes spans return a canceled context.
+	cancel bool
+}
+
+func (tr *recordingTracer) StartSpan(ctx context.Context, name string) (context.Context, func(error)) {
+	tr.spans = append(tr.spans, name)
+	if tr.cancel {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithCancel(ctx)
+		cancel()
+	}
+	return ctx, func(err error) {
+		tr.errs = append(tr.errs, err)
+	}
+}
+
+func TestWithTracer(t *testing.T) {
+	setup()
+	defer teardown()
+
+	mux.HandleFunc("/session/123/element", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"status": 0, "value": {"ELEMENT": "e1"}}`)
+	})
+
+	tracer := new(recordingTracer)
+	wd, err := NewRemote(caps, server.URL, WithTracer(tracer))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := wd.FindElement(ById, "q"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := wd.IsElementPresent(ById, "q"); err != nil {
+		t.Fatal(err)
+	}
+	// Commands sent from callbacks are named after the command too.
+	if _, err := wd.WaitForElement(ById, "q", time.Second, time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	err = NewWait(wd, time.Second, time.Millisecond).Until(func(wd WebDriver) (bool, error) {
+		_, err := wd.FindElement(ById, "q")
+		return err == nil, err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"NewSession", "FindElement", "FindElement", "FindElement", "FindElement"}
+	if !reflect.DeepEqual(tracer.spans, want) {
+		t.Errorf("Got spans %q, want %q", tracer.spans, want)
+	}
+	if len(tracer.errs) != len(want) {
+		t.Errorf("Ended %d spans, want %d", len(tracer.errs), len(want))
+	}
+
+	// The request is sent with the span's context.
+	tracer.cancel = true
+	if _, err := wd.FindElement(ById, "q"); err == nil {
+		t.Error("FindElement succeeded with a canceled span context")
+	}
+	if err := tracer.errs[len(tracer.errs)-1]; err == nil {
+		t.Error("Span ended without the command's error")
+	}
+}
+
+// BenchmarkConcurrentSessions reports how many connections are opened per
+// round of commands sent concurrently by 10 sessions, with the default client
+// and with a client keeping Go's default of 2 idle connections per host.
+func BenchmarkConcurrentSessions(b *testing.B) {
+	const sessions = 10
+	for _, bc := range []struct {
+		name   string
+		client *http.Client
+	}{
+		{"Default", nil},
+		{"GoDefaultTransport", &http.Client{Transport: &http.Transport{}}},
+	} {
+		b.Run(bc.name, func(b *testing.B) {
+			mux := http.NewServeMux()
+			mux.HandleFunc("/session", func(w http.ResponseWriter, r *http.Request) {
+				fmt.Fprint(w, `{"sessionId": "123"}`)
+			})
+			mux.HandleFunc("/session/123/url", func(w http.ResponseWriter, r *http.Request) {
+				// Keep the requests of a round in flight together.
+				time.Sleep(time.Millisecond)
+				fmt.Fprint(w, `{"status": 0, "value": "http://example.com/"}`)
+			})
+			var newConns int64
+			server := httptest.NewUnstartedServer(mux)
+			server.Config.ConnState = func(c net.Conn, state http.ConnState) {
+				if state == http.StateNew {
+					atomic.AddInt64(&newConns, 1)
+				}
+			}
+			server.Start()
+			defer server.Close()
+
+			opts := []DriverOption{WithLogger(log.New(ioutil.Discard, "", 0))}
+			if bc.client != nil {
+				opts = append(opts, WithHTTPClient(bc.client))
+				defer bc.client.CloseIdleConnections()
+			} else {
+				defer httpClient.CloseIdleConnections()
+			}
+			drivers := make([]WebDriver, sessions)
+			for i := range drivers {
+				wd, err := NewRemote(caps, server.URL, opts...)
+				if err != nil {
+					b.Fatal(err)
+				}
+				drivers[i] = wd
+			}
+
+			atomic.StoreInt64(&newConns, 0)
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				var wg sync.WaitGroup
+				for _, wd := range drivers {
+					wg.Add(1)
+					go func(wd WebDriver) {
+						defer wg.Done()
+						if _, err := wd.CurrentURL(); err != nil {
+							b.Error(err)
+						}
+					}(wd)
+				}
+				wg.Wait()
+			}
+			b.ReportMetric(float64(atomic.LoadInt64(&newConns))/float64(b.N), "conns/op")
+		})
+	}
+}
+
+func TestPool(t *testing.T) {
+	testPool(t, false)
+}
+
+func TestPool_W3C(t *testing.T) {
+	testPool(t, true)
+}
+
+func testPool(t *testing.T, w3c bool) {
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	sessionReply, handlePath := `{"sessionId": "123"}`, "/session/123/window_handle"
+	if w3c {
+		sessionReply, handlePath = `{"value": {"sessionId": "123", "capabilities": {}}}`, "/session/123/window"
+	}
+	var sessions, quits int32
+	mux.HandleFunc("/session", func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&sessions, 1)
+		fmt.Fprint(w, sessionReply)
+	})
+	mux.HandleFunc("/session/123", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "DELETE")
+		atomic.AddInt32(&quits, 1)
+	})
+	var crashed, checks int32
+	mux.HandleFunc(handlePath, func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		atomic.AddInt32(&checks, 1)
+		if atomic.CompareAndSwapInt32(&crashed, 1, 0) {
+			w.WriteHeader(http.StatusInternalServerError)
+			if w3c {
+				fmt.Fprint(w, `{"value": {"error": "unknown error", "message": "chrome not reachable"}}`)
+			} else {
+				fmt.Fprint(w, `{"status": 13, "value": {"message": "chrome not reachable"}}`)
+			}
+			return
+		}
+		fmt.Fprint(w, `{"status": 0, "value": "w1"}`)
+	})
+	var cookiesCleared, blank int32
+	mux.HandleFunc("/session/123/cookie", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "DELETE")
+		atomic.AddInt32(&cookiesCleared, 1)
+	})
+	mux.HandleFunc("/session/123/url", func(w http.ResponseWriter, r *http.Request) {
+		var v map[string]string
+		json.NewDecoder(r.Body).Decode(&v)
+		if v["url"] == "about:blank" {
+			atomic.AddInt32(&blank, 1)
+		}
+	})
+
+	pool, err := NewPool(caps, server.URL, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := atomic.LoadInt32(&sessions); n != 2 {
+		t.Errorf("NewPool started %d sessions, want 2", n)
+	}
+
+	wd1, err := pool.Acquire()
+	if err != nil {
+		t.Fatal(err)
+	}
+	wd2, err := pool.Acquire()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if wd1 == wd2 {
+		t.Error("Acquire returned the same session twice")
+	}
+	if n := atomic.LoadInt32(&checks); n != 2 {
+		t.Errorf("Acquire checked %d sessions, want 2", n)
+	}
+	if n := atomic.LoadInt32(&sessions); n != 2 {
+		t.Errorf("Got %d sessions after acquiring healthy ones, want 2", n)
+	}
+
+	if err := pool.Release(wd1); err != nil {
+		t.Fatal(err)
+	}
+	if atomic.LoadInt32(&cookiesCleared) != 1 || atomic.LoadInt32(&blank) != 1 {
+		t.Error("Release did not clear the cookies and load about:blank")
+	}
+
+	atomic.StoreInt32(&crashed, 1)
+	wd3, err := pool.Acquire()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if wd3 == wd1 {
+		t.Error("Acquire returned a session whose browser crashed")
+	}
+	if n := atomic.LoadInt32(&sessions); n != 3 {
+		t.Errorf("Got %d sessions, want 3 after replacing the crashed one", n)
+	}
+
+	if err := pool.Release(wd2); err != nil {
+		t.Fatal(err)
+	}
+	if err := pool.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if n := atomic.LoadInt32(&quits); n != 3 {
+		t.Errorf("Got %d quits, want 3", n)
+	}
+	if _, err := pool.Acquire(); err != ErrPoolClosed {
+		t.Errorf("Acquire after Close returned %v, want %v", err, ErrPoolClosed)
+	}
+}
+
+func TestPool_ReleaseNotAcquired(t *testing.T) {
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	mux.HandleFunc("/session", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"sessionId": "123"}`)
+	})
+	mux.HandleFunc("/session/123", func(w http.ResponseWriter, r *http.Request) {})
+	mux.HandleFunc("/session/123/window_handle", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"status": 0, "value": "w1"}`)
+	})
+	mux.HandleFunc("/session/123/cookie", func(w http.ResponseWriter, r *http.Request) {})
+	mux.HandleFunc("/session/123/url", func(w http.ResponseWriter, r *http.Request) {})
+
+	pool, err := NewPool(caps, server.URL, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pool.Close()
+
+	wd, err := pool.Acquire()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := pool.Release(wd); err != nil {
+		t.Fatal(err)
+	}
+	if err := pool.Release(wd); err != ErrNotAcquired {
+		t.Errorf("Releasing a session twice returned %v, want %v", err, ErrNotAcquired)
+	}
+	other, err := NewRemote(caps, server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := pool.Release(other); err != ErrNotAcquired {
+		t.Errorf("Releasing a session not from the pool returned %v, want %v", err, ErrNotAcquired)
+	}
+
+	// The pool still holds one idle session.
+	done := make(chan error, 1)
+	go func() {
+		wd, err := pool.Acquire()
+		if err == nil {
+			err = pool.Release(wd)
+		}
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Acquire blocked after the bad releases")
+	}
+	if n := len(pool.idle); n != 1 {
+		t.Errorf("The pool has %d idle sessions, want 1", n)
+	}
+}
+
+func TestNewTab(t *testing.T) {
+	setupW3C()
+	defer teardown()
+
+	mux.HandleFunc("/session/123", func(w http.ResponseWriter, r *http.Request) {
+		t.Error("Quit on a tab deleted the session")
+	})
+	mux.HandleFunc("/session/123/window/new", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"value": {"handle": "t2", "type": "tab"}}`)
+	})
+	var switches []string
+	closed := false
+	mux.HandleFunc("/session/123/window", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case "GET":
+			fmt.Fprint(w, `{"value": "t1"}`)
+			return
+		case "DELETE":
+			closed = true
+			fmt.Fprint(w, `{"value": ["t1"]}`)
+			return
+		}
+		var v map[string]string
+		json.NewDecoder(r.Body).Decode(&v)
+		switches = append(switches, v["handle"])
+		fmt.Fprint(w, `{"value": null}`)
+	})
+	var frames []interface{}
+	mux.HandleFunc("/session/123/frame", func(w http.ResponseWriter, r *http.Request) {
+		var v map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&v)
+		frames = append(frames, v["id"])
+		fmt.Fprint(w, `{"value": null}`)
+	})
+	mux.HandleFunc("/session/123/element", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"value": {"%s": "f1"}}`, webElementKey)
+	})
+	mux.HandleFunc("/session/123/title", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"value": "title"}`)
+	})
+
+	tab, err := client.NewTab()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id := tab.GetSessionID(); id != "123" {
+		t.Errorf("GetSessionID = %q, want %q", id, "123")
+	}
+	title := func(wd WebDriver) {
+		t.Helper()
+		if _, err := wd.Title(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	title(tab)
+	title(tab)
+	if want := []string{"t2"}; !reflect.DeepEqual(switches, want) {
+		t.Errorf("Tab switched to %v, want %v", switches, want)
+	}
+	title(client)
+	if want := []string{"t2", "t1"}; !reflect.DeepEqual(switches, want) {
+		t.Errorf("Session driver switched to %v, want %v", switches, want)
+	}
+
+	// The tab's frame survives the session driver's commands.
+	if err := tab.SwitchFrame("f"); err != nil {
+		t.Fatal(err)
+	}
+	title(client)
+	title(tab)
+	if want := []string{"t2", "t1", "t2", "t1", "t2"}; !reflect.DeepEqual(switches, want) {
+		t.Errorf("Switched to %v, want %v", switches, want)
+	}
+	ref := map[string]interface{}{"ELEMENT": "f1", webElementKey: "f1"}
+	if want := []interface{}{ref, ref}; !reflect.DeepEqual(frames, want) {
+		t.Errorf("Switched to frames %v, want %v", frames, want)
+	}
+	if path := tab.CurrentFramePath(); !reflect.DeepEqual(path, []string{"f"}) {
+		t.Errorf("Tab CurrentFramePath = %q, want %q", path, []string{"f"})
+	}
+	if path := client.CurrentFramePath(); path != nil {
+		t.Errorf("Session driver CurrentFramePath = %q, want none", path)
+	}
+
+	if err := tab.Quit(); err != nil {
+		t.Fatal(err)
+	}
+	if !closed {
+		t.Error("Quit did not close the tab")
+	}
+	title(client)
+	if want := []string{"t2", "t1", "t2", "t1", "t2", "t1"}; !reflect.DeepEqual(switches, want) {
+		t.Errorf("Switched to %v, want %v", switches, want)
+	}
+}
+
+func TestRetryOnStale(t *testing.T) {
+	setup()
+	defer teardown()
+
+	finds := 0
+	mux.HandleFunc("/session/123/element", func(w http.ResponseWriter, r *http.Request) {
+		finds++
+		fmt.Fprintf(w, `{"status": 0, "value": {"ELEMENT": "e%d"}}`, finds)
+	})
+	mux.HandleFunc("/session/123/element/e1/click", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+		fmt.Fprint(w, `{"status": 10, "value": {"message": "element is not attached to the page document"}}`)
+	})
+	mux.HandleFunc("/session/123/element/e2/click", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"status": 0, "value": null}`)
+	})
+
+	calls := 0
+	click := func() error {
+		calls++
+		elem, err := client.FindElement(ById, "submit")
+		if err != nil {
+			return err
+		}
+		return elem.Click()
+	}
+	if err := RetryOnStale(3, click); err != nil {
+		t.Fatalf("RetryOnStale returned error: %v", err)
+	}
+	if calls != 2 {
+		t.Errorf("fn was called %d times, want 2", calls)
+	}
+
+	for _, attempts := range []int{1, 0, -1} {
+		finds, calls = 0, 0
+		err := RetryOnStale(attempts, click)
+		if !errors.Is(err, ErrStaleElement) {
+			t.Errorf("RetryOnStale(%d) returned %v, want a stale element error", attempts, err)
+		}
+		if calls != 1 {
+			t.Errorf("RetryOnStale(%d) called fn %d times, want 1", attempts, calls)
+		}
+	}
+}
+
+func TestElementContextClick(t *testing.T) {
+	setupW3C()
+	defer teardown()
+
+	var got struct {
+		Actions []struct {
+			Actions []map[string]interface{} `json:"actions"`
+		} `json:"actions"`
+	}
+	mux.HandleFunc("/session/123/actions", func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&got)
+		fmt.Fprint(w, `{"value": null}`)
+	})
+
+	elem := &remoteWE{client.(*remoteWebDriver), "e1"}
+	if err := elem.ContextClick(); err != nil {
+		t.Fatalf("ContextClick returned error: %v", err)
+	}
+	want := []map[string]interface{}{
+		{"type": "pointerMove", "x": 0.0, "y": 0.0, "duration": 0.0, "origin": map[string]interface{}{webElementKey: "e1"}},
+		{"type": "pointerDown", "button": float64(RightButton)},
+		{"type": "pointerUp", "button": float64(RightButton)},
+	}
+	if len(got.Actions) != 1 || !reflect.DeepEqual(got.Actions[0].Actions, want) {
+		t.Errorf("ContextClick sent %v, want %v", got.Actions, want)
+	}
+}
+
+func TestElementDoubleClick_JSONWire(t *testing.T) {
+	setup()
+	defer teardown()
+
+	var requests []string
+	mux.HandleFunc("/session/123/moveto", func(w http.ResponseWriter, r *http.Request) {
+		var v map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&v)
+		if want := map[string]interface{}{"element": "e1"}; !reflect.DeepEqual(v, want) {
+			t.Errorf("moveto body = %v, want %v", v, want)
+		}
+		requests = append(requests, "moveto")
+		fmt.Fprint(w, `{"status": 0}`)
+	})
+	mux.HandleFunc("/session/123/doubleclick", func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, "doubleclick")
+		fmt.Fprint(w, `{"status": 0}`)
+	})
+
+	elem := &remoteWE{client.(*remoteWebDriver), "e1"}
+	if err := elem.DoubleClick(); err != nil {
+		t.Fatalf("DoubleClick returned error: %v", err)
+	}
+	if want := []string{"moveto", "doubleclick"}; !reflect.DeepEqual(requests, want) {
+		t.Errorf("DoubleClick sent %v, want %v", requests, want)
+	}
+}
+
+func TestTouch(t *testing.T) {
+	setup()
+	defer teardown()
+
+	got := make(map[string]map[string]interface{})
+	for _, cmd := range []string{"click", "longclick", "down", "scroll", "flick"} {
+		cmd := cmd
+		mux.HandleFunc("/session/123/touch/"+cmd, func(w http.ResponseWriter, r *http.Request) {
+			testMethod(t, r, "POST")
+			var v map[string]interface{}
+			json.NewDecoder(r.Body).Decode(&v)
+			got[cmd] = v
+			fmt.Fprint(w, `{"status": 0}`)
+		})
+	}
+
+	elem := &remoteWE{client.(*remoteWebDriver), "e1"}
+	if err := client.Tap(elem); err != nil {
+		t.Fatalf("Tap returned error: %v", err)
+	}
+	if err := client.LongPress(elem); err != nil {
+		t.Fatalf("LongPress returned error: %v", err)
+	}
+	if err := client.TouchDown(10, 20); err != nil {
+		t.Fatalf("TouchDown returned error: %v", err)
+	}
+	if err := client.TouchScroll(nil, 0, 100); err != nil {
+		t.Fatalf("TouchScroll returned error: %v", err)
+	}
+	if err := client.TouchFlick(elem, -50, 0, 800); err != nil {
+		t.Fatalf("TouchFlick returned error: %v", err)
+	}
+
+	want := map[string]map[string]interface{}{
+		"click":     {"element": "e1"},
+		"longclick": {"element": "e1"},
+		"down":      {"x": 10.0, "y": 20.0},
+		"scroll":    {"xoffset": 0.0, "yoffset": 100.0},
+		"flick":     {"element": "e1", "xoffset": -50.0, "yoffset": 0.0, "speed": 800.0},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Touch commands sent %v, want %v", got, want)
+	}
+
+	if err := client.Tap(nil); err == nil {
+		t.Error("Tap(nil) returned no error")
+	}
+	if err := client.TouchFlick(nil, -50, 0, 800); err == nil {
+		t.Error("TouchFlick(nil) returned no error")
+	}
+	if err := client.TouchScroll(struct{ WebElement }{}, 0, 100); err == nil {
+		t.Error("TouchScroll with another WebElement returned no error")
+	}
+}
+
+func TestWaitForElement(t *testing.T) {
+	setup()
+	defer teardown()
+
+	finds := 0
+	mux.HandleFunc("/session/123/element", func(w http.ResponseWriter, r *http.Request) {
+		finds++
+		if finds < 3 {
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"status": 7, "value": {"message": "no such element"}}`)
+			return
+		}
+		fmt.Fprint(w, `{"status": 0, "value": {"ELEMENT": "e1"}}`)
+	})
+	displayed := 0
+	mux.HandleFunc("/session/123/element/e1/displayed", func(w http.ResponseWriter, r *http.Request) {
+		displayed++
+		fmt.Fprintf(w, `{"status": 0, "value": %t}`, displayed > 1)
+	})
+
+	elem, err := client.WaitForElement(ById, "spinner", time.Second, time.Millisecond)
+	if err != nil {
+		t.Fatalf("WaitForElement returned error: %v", err)
+	}
+	if elem.(*remoteWE).id != "e1" || finds != 3 {
+		t.Errorf("WaitForElement returned %v after %d finds, want e1 after 3", elem, finds)
+	}
+
+	if _, err := client.WaitForVisibleElement(ById, "spinner", time.Second, time.Millisecond); err != nil {
+		t.Fatalf("WaitForVisibleElement returned error: %v", err)
+	}
+	if displayed != 2 {
+		t.Errorf("WaitForVisibleElement checked visibility %d times, want 2", displayed)
+	}
+}
+
+func TestWaitForElement_Timeout(t *testing.T) {
+	setup()
+	defer teardown()
+
+	mux.HandleFunc("/session/123/element", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprint(w, `{"status": 7, "value": {"message": "no such element"}}`)
+	})
+
+	if _, err := client.WaitForElement(ById, "missing", 20*time.Millisecond, time.Millisecond); err != ErrWaitTimeout {
+		t.Errorf("WaitForElement returned %v, want %v", err, ErrWaitTimeout)
+	}
+}
+
+func TestWaitForElementGone_Stale(t *testing.T) {
+	setup()
+	defer teardown()
+
+	finds := 0
+	mux.HandleFunc("/session/123/element", func(w http.ResponseWriter, r *http.Request) {
+		finds++
+		fmt.Fprint(w, `{"status": 0, "value": {"ELEMENT": "e1"}}`)
+	})
+	mux.HandleFunc("/session/123/element/e1/displayed", func(w http.ResponseWriter, r *http.Request) {
+		if finds < 3 {
+			fmt.Fprint(w, `{"status": 0, "value": true}`)
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprint(w, `{"status": 10, "value": {"message": "element is not attached to the page document"}}`)
+	})
+
+	if err := client.WaitForElementGone(ById, "spinner", time.Second, time.Millisecond); err != nil {
+		t.Fatalf("WaitForElementGone returned error: %v", err)
+	}
+	if finds != 3 {
+		t.Errorf("WaitForElementGone found the element %d times, want 3", finds)
+	}
+}
+
+func TestSendAlertCredentials(t *testing.T) {
+	setupW3C()
+	defer teardown()
+
+	var got map[string]string
+	mux.HandleFunc("/session/123/alert/credentials", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "POST")
+		json.NewDecoder(r.Body).Decode(&got)
+		fmt.Fprint(w, `{"value": null}`)
+	})
+
+	if err := client.SendAlertCredentials("user", "pass"); err != nil {
+		t.Fatalf("SendAlertCredentials returned error: %v", err)
+	}
+	if want := map[string]string{"username": "user", "password": "pass"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("SendAlertCredentials sent %v, want %v", got, want)
+	}
+}
+
+func TestSendAlertCredentials_NotSupported(t *testing.T) {
+	setupW3C()
+	defer teardown()
+
+	mux.HandleFunc("/session/123/alert/credentials", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprint(w, `{"value": {"error": "unknown command", "message": "unknown command"}}`)
+	})
+
+	if err := client.SendAlertCredentials("user", "pass"); err != ErrNotSupported {
+		t.Errorf("SendAlertCredentials returned error %v, want %v", err, ErrNotSupported)
+	}
+}
+
+func TestElementRect(t *testing.T) {
+	setupW3C()
+	defer teardown()
+
+	mux.HandleFunc("/session/123/element/e1/rect", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"value": {"x": 10, "y": 20, "width": 100, "height": 50}}`)
+	})
+
+	elem := &remoteWE{client.(*remoteWebDriver), "e1"}
+	rect, err := elem.Rect()
+	if err != nil {
+		t.Fatalf("Rect returned error: %v", err)
+	}
+	if want := (Rect{X: 10, Y: 20, Width: 100, Height: 50}); *rect != want {
+		t.Errorf("Rect = %+v, want %+v", *rect, want)
+	}
+}
+
+func TestElementRect_JSONWire(t *testing.T) {
+	setup()
+	defer teardown()
+
+	mux.HandleFunc("/session/123/element/e1/location", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"status": 0, "value": {"x": 10, "y": 20}}`)
+	})
+	mux.HandleFunc("/session/123/element/e1/size", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"status": 0, "value": {"width": 100, "height": 50}}`)
+	})
+
+	elem := &remoteWE{client.(*remoteWebDriver), "e1"}
+	rect, err := elem.Rect()
+	if err != nil {
+		t.Fatalf("Rect returned error: %v", err)
+	}
+	if want := (Rect{X: 10, Y: 20, Width: 100, Height: 50}); *rect != want {
+		t.Errorf("Rect = %+v, want %+v", *rect, want)
+	}
+}
+
+func TestAttributes_W3C(t *testing.T) {
+	setupW3C()
+	defer teardown()
+
+	mux.HandleFunc("/session/123/execute", func(w http.ResponseWriter, r *http.Request) {
+		var v struct {
+			Args []map[string]string `json:"args"`
+		}
+		json.NewDecoder(r.Body).Decode(&v)
+		if len(v.Args) != 1 || v.Args[0][webElementKey] != "e1" {
+			t.Errorf("Script args = %v, want a reference to e1", v.Args)
+		}
+		fmt.Fprint(w, `{"value": {"id": "name", "class": "field wide", "required": ""}}`)
+	})
+
+	elem := &remoteWE{client.(*remoteWebDriver), "e1"}
+	attrs, err := elem.Attributes()
+	if err != nil {
+		t.Fatalf("Attributes returned error: %v", err)
+	}
+	want := map[string]string{"id": "name", "class": "field wide", "required": ""}
+	if !reflect.DeepEqual(attrs, want) {
+		t.Errorf("Attributes = %v, want %v", attrs, want)
+	}
+}
+
+func TestSessionCapabilities(t *testing.T) {
+	setupSession(`{"value": {"sessionId": "123", "capabilities": {"browserName": "firefox", "browserVersion": "115.0", "platformName": "linux", "acceptInsecureCerts": true, "pageLoadStrategy": "eager", "moz:headless": true}}}`)
+	defer teardown()
+
+	info, err := client.SessionCapabilities()
+	if err != nil {
+		t.Fatalf("SessionCapabilities returned error: %v", err)
+	}
+	if info.BrowserName != "firefox" || info.BrowserVersion != "115.0" || info.PlatformName != "linux" ||
+		!info.AcceptInsecureCerts || info.PageLoadStrategy != PageLoadEager {
+		t.Errorf("SessionCapabilities = %+v", info)
+	}
+	if info.Raw["moz:headless"] != true {
+		t.Errorf("Raw capabilities are %v, want moz:headless", info.Raw)
+	}
+}
+
+func TestSessionCapabilities_JSONWire(t *testing.T) {
+	setup()
+	defer teardown()
+
+	mux.HandleFunc("/session/123", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		fmt.Fprint(w, `{"status": 0, "value": {"browserName": "chrome", "version": "60.0", "platform": "LINUX"}}`)
+	})
+
+	info, err := client.SessionCapabilities()
+	if err != nil {
+		t.Fatalf("SessionCapabilities returned error: %v", err)
+	}
+	if info.BrowserName != "chrome" || info.BrowserVersion != "60.0" || info.PlatformName != "LINUX" {
+		t.Errorf("SessionCapabilities = %+v", info)
+	}
+}
+
+func TestSafeClick(t *testing.T) {
+	setupW3C()
+	defer teardown()
+
+	mux.HandleFunc("/session/123/execute", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"value": null}`)
+	})
+	displayed := 0
+	mux.HandleFunc("/session/123/element/e1/displayed", func(w http.ResponseWriter, r *http.Request) {
+		displayed++
+		fmt.Fprintf(w, `{"value": %t}`, displayed > 1)
+	})
+	mux.HandleFunc("/session/123/element/e1/enabled", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"value": true}`)
+	})
+	clicks := 0
+	mux.HandleFunc("/session/123/element/e1/click", func(w http.ResponseWriter, r *http.Request) {
+		clicks++
+		if clicks == 1 {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, `{"value": {"error": "element click intercepted", "message": "Other element would receive the click"}}`)
+			return
+		}
+		fmt.Fprint(w, `{"value": null}`)
+	})
+
+	elem := &remoteWE{client.(*remoteWebDriver), "e1"}
+	if err := elem.SafeClick(); err != nil {
+		t.Fatalf("SafeClick returned error: %v", err)
+	}
+	if displayed != 2 || clicks != 2 {
+		t.Errorf("SafeClick checked visibility %d times and clicked %d times, want 2 and 2", displayed, clicks)
+	}
+}
+
+func TestJSClick(t *testing.T) {
+	setupW3C()
+	defer teardown()
+
+	var got struct {
+		Script string                   `json:"script"`
+		Args   []map[string]interface{} `json:"args"`
+	}
+	mux.HandleFunc("/session/123/execute", func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&got)
+		fmt.Fprint(w, `{"value": null}`)
+	})
+
+	elem := &remoteWE{client.(*remoteWebDriver), "e1"}
+	if err := elem.JSClick(); err != nil {
+		t.Fatalf("JSClick returned error: %v", err)
+	}
+	want := []map[string]interface{}{{"ELEMENT": "e1", webElementKey: "e1"}}
+	if got.Script != "arguments[0].click();" || !reflect.DeepEqual(got.Args, want) {
+		t.Errorf("JSClick ran %q with %v, want the element %v", got.Script, got.Args, want)
+	}
+}
+
+func TestParseCSSColor(t *testing.T) {
+	tests := []struct {
+		value string
+		want  color.RGBA
+	}{
+		{"rgb(255, 0, 0)", color.RGBA{255, 0, 0, 255}},
+		{"rgba(0, 128, 255, 1)", color.RGBA{0, 128, 255, 255}},
+		{"rgba(255, 255, 255, 0.5)", color.RGBA{128, 128, 128, 128}},
+		{"rgb(0 255 0 / 100%)", color.RGBA{0, 255, 0, 255}},
+		{"transparent", color.RGBA{}},
+		{"#0f0", color.RGBA{0, 255, 0, 255}},
+		{"#336699", color.RGBA{0x33, 0x66, 0x99, 255}},
+	}
+	for _, tt := range tests {
+		got, err := parseCSSColor(tt.value)
+		if err != nil {
+			t.Errorf("parseCSSColor(%q) returned error: %v", tt.value, err)
+		} else if got != tt.want {
+			t.Errorf("parseCSSColor(%q) = %v, want %v", tt.value, got, tt.want)
+		}
+	}
+	for _, value := range []string{"red", "rgb(1, 2)", "rgb(300, 0, 0)", "#12345"} {
+		if _, err := parseCSSColor(value); err == nil {
+			t.Errorf("parseCSSColor(%q) returned no error", value)
+		}
+	}
+}
+
+func TestCSSPixels(t *testing.T) {
+	setup()
+	defer teardown()
+
+	mux.HandleFunc("/session/123/element/e1/css/font-size", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"status": 0, "value": "13.5px"}`)
+	})
+	mux.HandleFunc("/session/123/element/e1/css/width", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"status": 0, "value": "auto"}`)
+	})
+
+	elem := &remoteWE{client.(*remoteWebDriver), "e1"}
+	if px, err := elem.CSSPixels("font-size"); err != nil || px != 13.5 {
+		t.Errorf("CSSPixels = %v, %v, want 13.5", px, err)
+	}
+	if _, err := elem.CSSPixels("width"); err == nil {
+		t.Error("CSSPixels of auto returned no error")
+	}
+}
+
+func TestNewRemoteSession(t *testing.T) {
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	mux.HandleFunc("/session", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"value": {"sessionId": "123", "capabilities": {"browserName": "chrome", "browserVersion": "120.0"}}}`)
+	})
+
+	wd, session, err := NewRemoteSession(caps, server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if wd.GetSessionID() != "123" || session.Id != "123" {
+		t.Errorf("Session id is %q, driver's %q, want %q", session.Id, wd.GetSessionID(), "123")
+	}
+	want := Capabilities{"browserName": "chrome", "browserVersion": "120.0"}
+	if !reflect.DeepEqual(session.Capabilities, want) {
+		t.Errorf("Session capabilities are %v, want %v", session.Capabilities, want)
+	}
+}
+
+func TestSessionNodeInfo(t *testing.T) {
+	setup()
+	defer teardown()
+
+	mux.HandleFunc("/grid/api/testsession", func(w http.ResponseWriter, r *http.Request) {
+		if id := r.URL.Query().Get("session"); id != "123" {
+			t.Errorf("Queried session %q, want %q", id, "123")
+		}
+		fmt.Fprint(w, `{"msg": "slot found !", "success": true, "session": "123", "internalKey": "k1", "inactivityTime": 42, "proxyId": "http://10.0.0.5:5555"}`)
+	})
+
+	info, err := client.SessionNodeInfo()
+	if err != nil {
+		t.Fatalf("SessionNodeInfo returned error: %v", err)
+	}
+	if want := (NodeInfo{NodeURL: "http://10.0.0.5:5555", InternalKey: "k1", InactivityTime: 42}); *info != want {
+		t.Errorf("SessionNodeInfo = %+v, want %+v", *info, want)
+	}
+}
+
+func TestSessionNodeInfo_Command(t *testing.T) {
+	setup()
+	defer teardown()
+
+	mux.HandleFunc("/grid/api/testsession", func(w http.ResponseWriter, r *http.Request) {
+		if accept := r.Header.Get("Accept"); accept != jsonMIMEType {
+			t.Errorf("Accept = %q, want %q", accept, jsonMIMEType)
+		}
+		fmt.Fprint(w, `{"success": true, "proxyId": "http://10.0.0.5:5555"}`)
+	})
+
+	var urls []string
+	wd, err := NewRemote(caps, server.URL, WithMetricsHook(func(method, url string, status int, duration time.Duration, err error) {
+		urls = append(urls, url)
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	urls = nil
+	if _, err := wd.SessionNodeInfo(); err != nil {
+		t.Fatalf("SessionNodeInfo returned error: %v", err)
+	}
+	if want := []string{server.URL + "/grid/api/testsession?session=123"}; !reflect.DeepEqual(urls, want) {
+		t.Errorf("Hook called for %q, want %q", urls, want)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	wd.SetCommandContext(ctx)
+	if _, err := wd.SessionNodeInfo(); err != ErrCanceled {
+		t.Errorf("SessionNodeInfo with a canceled command context returned error %v, want %v", err, ErrCanceled)
+	}
+}
+
+func TestSessionNodeInfo_Standalone(t *testing.T) {
+	setup()
+	defer teardown()
+
+	if _, err := client.SessionNodeInfo(); err != ErrNotSupported {
+		t.Errorf("SessionNodeInfo returned error %v, want %v", err, ErrNotSupported)
+	}
+}
+
+func TestCurrentWindowRect(t *testing.T) {
+	setupW3C()
+	defer teardown()
+
+	mux.HandleFunc("/session/123/window/rect", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		fmt.Fprint(w, `{"value": {"x": 10, "y": 20, "width": 1024, "height": 768}}`)
+	})
+
+	rect, err := client.CurrentWindowRect()
+	if err != nil {
+		t.Fatalf("CurrentWindowRect returned error: %v", err)
+	}
+	if want := (Rect{X: 10, Y: 20, Width: 1024, Height: 768}); *rect != want {
+		t.Errorf("CurrentWindowRect = %+v, want %+v", *rect, want)
+	}
+}
+
+func TestCurrentWindowRect_JSONWire(t *testing.T) {
+	setup()
+	defer teardown()
+
+	mux.HandleFunc("/session/123/window/current/position", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"status": 0, "value": {"x": 10, "y": 20}}`)
+	})
+	mux.HandleFunc("/session/123/window/current/size", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"status": 0, "value": {"width": 1024, "height": 768}}`)
+	})
+
+	rect, err := client.CurrentWindowRect()
+	if err != nil {
+		t.Fatalf("CurrentWindowRect returned error: %v", err)
+	}
+	if want := (Rect{X: 10, Y: 20, Width: 1024, Height: 768}); *rect != want {
+		t.Errorf("CurrentWindowRect = %+v, want %+v", *rect, want)
+	}
+}
+
+func TestExecute(t *testing.T) {
+	setupW3C()
+	defer teardown()
+
+	mux.HandleFunc("/session/123/se/experimental", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "POST")
+		var v map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&v)
+		if v["enabled"] != true {
+			t.Errorf("Execute sent %v", v)
+		}
+		fmt.Fprint(w, `{"value": {"answer": 42}}`)
+	})
+
+	value, err := client.Execute("POST", "/session/%s/se/experimental", map[string]bool{"enabled": true})
+	if err != nil {
+		t.Fatalf("Execute returned error: %v", err)
+	}
+	if string(value) != `{"answer": 42}` {
+		t.Errorf("Execute returned %s, want %s", value, `{"answer": 42}`)
+	}
+}
+
+func TestExecute_EscapedPath(t *testing.T) {
+	setupW3C()
+	defer teardown()
+
+	var paths []string
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.EscapedPath())
+		fmt.Fprint(w, `{"value": null}`)
+	})
+
+	for _, path := range []string{"/foo%20bar", "/session/%s/foo%20bar"} {
+		if _, err := client.Execute("GET", path, nil); err != nil {
+			t.Fatalf("Execute(%q) returned error: %v", path, err)
+		}
+	}
+	if want := []string{"/foo%20bar", "/session/123/foo%20bar"}; !reflect.DeepEqual(paths, want) {
+		t.Errorf("Execute requested %q, want %q", paths, want)
+	}
+}
+
+func TestUnexpectedAlertOpen(t *testing.T) {
+	setupW3C()
+	defer teardown()
+
+	mux.HandleFunc("/session/123/title", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+		fmt.Fprint(w, `{"value": {"error": "unexpected alert open", "message": "unexpected alert open: {Alert text : Are you sure?}", "data": {"text": "Are you sure?"}}}`)
+	})
+
+	_, err := client.Title()
+	var alertErr *ErrUnexpectedAlertOpen
+	if !errors.As(err, &alertErr) {
+		t.Fatalf("Title returned error %#v, want an ErrUnexpectedAlertOpen", err)
+	}
+	if alertErr.Text != "Are you sure?" {
+		t.Errorf("Alert text = %q, want %q", alertErr.Text, "Are you sure?")
+	}
+	if code, ok := ErrorCode(err); !ok || code != 26 {
+		t.Errorf("ErrorCode = %d, %t, want 26", code, ok)
+	}
+}
+
+func TestUnexpectedAlertOpen_JSONWire(t *testing.T) {
+	setup()
+	defer teardown()
+
+	mux.HandleFunc("/session/123/title", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+		fmt.Fprint(w, `{"status": 26, "value": {"message": "unexpected alert open", "alert": {"text": "Are you sure?"}}}`)
+	})
+
+	_, err := client.Title()
+	alertErr, ok := err.(*ErrUnexpectedAlertOpen)
+	if !ok || alertErr.Text != "Are you sure?" {
+		t.Errorf("Title returned error %#v, want an ErrUnexpectedAlertOpen with the alert text", err)
+	}
+}
+
+func TestWaitAlertAndAccept(t *testing.T) {
+	setup()
+	defer teardown()
+
+	polls := 0
+	mux.HandleFunc("/session/123/alert_text", func(w http.ResponseWriter, r *http.Request) {
+		polls++
+		if polls < 3 {
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"status": 27, "value": {"message": "no alert open"}}`)
+			return
+		}
+		fmt.Fprint(w, `{"status": 0, "value": "Saved"}`)
+	})
+	accepted := false
+	mux.HandleFunc("/session/123/accept_alert", func(w http.ResponseWriter, r *http.Request) {
+		accepted = true
+		fmt.Fprint(w, `{"status": 0}`)
+	})
+
+	text, err := client.WaitAlertAndAccept(time.Second)
+	if err != nil {
+		t.Fatalf("WaitAlertAndAccept returned error: %v", err)
+	}
+	if text != "Saved" || !accepted {
+		t.Errorf("WaitAlertAndAccept returned %q, accepted: %t", text, accepted)
+	}
+}
+
+func TestWaitAlertAndDismiss_Timeout(t *testing.T) {
+	setup()
+	defer teardown()
+
+	mux.HandleFunc("/session/123/alert_text", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprint(w, `{"status": 27, "value": {"message": "no alert open"}}`)
+	})
+
+	if _, err := client.WaitAlertAndDismiss(50 * time.Millisecond); err != ErrWaitTimeout {
+		t.Errorf("WaitAlertAndDismiss returned %v, want %v", err, ErrWaitTimeout)
+	}
+}
+
+func TestAlertPaths(t *testing.T) {
+	for _, tt := range []struct {
+		w3c      bool
+		requests []string
+	}{
+		{false, []string{
+			"POST /session/123/dismiss_alert",
+			"POST /session/123/accept_alert",
+			"GET /session/123/alert_text",
+			"POST /session/123/alert_text",
+		}},
+		{true, []string{
+			"POST /session/123/alert/dismiss",
+			"POST /session/123/alert/accept",
+			"GET /session/123/alert/text",
+			"POST /session/123/alert/text",
+		}},
+	} {
+		if tt.w3c {
+			setupW3C()
+		} else {
+			setup()
+		}
+
+		var requests []string
+		mux.HandleFunc("/session/123/", func(w http.ResponseWriter, r *http.Request) {
+			requests = append(requests, r.Method+" "+r.URL.Path)
+			fmt.Fprint(w, `{"status": 0, "value": "text"}`)
+		})
+
+		if err := client.DismissAlert(); err != nil {
+			t.Errorf("DismissAlert returned error: %v", err)
+		}
+		if err := client.AcceptAlert(); err != nil {
+			t.Errorf("AcceptAlert returned error: %v", err)
+		}
+		if _, err := client.AlertText(); err != nil {
+			t.Errorf("AlertText returned error: %v", err)
+		}
+		if err := client.SetAlertText("text"); err != nil {
+			t.Errorf("SetAlertText returned error: %v", err)
+		}
+		if !reflect.DeepEqual(requests, tt.requests) {
+			t.Errorf("Alert commands with W3C %t sent %v, want %v", tt.w3c, requests, tt.requests)
+		}
+		teardown()
+	}
+}
+
+func TestSendKeysBody(t *testing.T) {
+	for _, tt := range []struct {
+		w3c  bool
+		keys string
+		want string
+	}{
+		{false, "hé!", `{"value":["h","é","!"]}`},
+		{false, "", `{"value":[]}`},
+		{true, "hé!", `{"text":"hé!"}`},
+	} {
+		if tt.w3c {
+			setupW3C()
+		} else {
+			setup()
+		}
+
+		var body string
+		mux.HandleFunc("/session/123/element/e1/value", func(w http.ResponseWriter, r *http.Request) {
+			testMethod(t, r, "POST")
+			b, _ := ioutil.ReadAll(r.Body)
+			body = string(b)
+			fmt.Fprint(w, `{"status": 0, "value": null}`)
+		})
+
+		elem := &remoteWE{client.(*remoteWebDriver), "e1"}
+		if err := elem.SendKeys(tt.keys); err != nil {
+			t.Errorf("SendKeys with W3C %t returned error: %v", tt.w3c, err)
+		}
+		if body != tt.want {
+			t.Errorf("SendKeys(%q) with W3C %t sent %s, want %s", tt.keys, tt.w3c, body, tt.want)
+		}
+		teardown()
+	}
+}
+
+func TestClientImplicitWait(t *testing.T) {
+	setup()
+	defer teardown()
+
+	finds := 0
+	mux.HandleFunc("/session/123/element", func(w http.ResponseWriter, r *http.Request) {
+		finds++
+		if finds < 3 {
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"status": 7, "value": {"message": "Unable to locate element"}}`)
+			return
+		}
+		fmt.Fprint(w, `{"status": 0, "value": {"ELEMENT": "e1"}}`)
+	})
+	mux.HandleFunc("/session/123/elements", func(w http.ResponseWriter, r *http.Request) {
+		finds++
+		if finds < 6 {
+			fmt.Fprint(w, `{"status": 0, "value": []}`)
+			return
+		}
+		fmt.Fprint(w, `{"status": 0, "value": [{"ELEMENT": "e1"}]}`)
+	})
+
+	if _, err := client.FindElement(ById, "late"); !isNoSuchElement(err) {
+		t.Fatalf("FindElement without ClientImplicitWait returned error %v, want no such element", err)
+	}
+	if finds != 1 {
+		t.Fatalf("FindElement without ClientImplicitWait sent %d finds, want 1", finds)
+	}
+
+	wd, err := NewRemote(caps, server.URL, ClientImplicitWait(time.Second))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := wd.FindElement(ById, "late"); err != nil {
+		t.Fatalf("FindElement returned error: %v", err)
+	}
+	if finds != 3 {
+		t.Errorf("FindElement sent %d finds, want 3", finds)
+	}
+	elems, err := wd.FindElements(ById, "late")
+	if err != nil {
+		t.Fatalf("FindElements returned error: %v", err)
+	}
+	if len(elems) != 1 || finds != 6 {
+		t.Errorf("FindElements returned %d elements after %d finds, want 1 after 6", len(elems), finds)
+	}
+}
+
+func TestCurrentFramePath(t *testing.T) {
+	setupW3C()
+	defer teardown()
+
+	mux.HandleFunc("/session/123/", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"value": null}`)
+	})
+	mux.HandleFunc("/session/123/element", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"value": {"%s": "f1"}}`, webElementKey)
+	})
+
+	check := func(after string, want []string) {
+		t.Helper()
+		if got := client.CurrentFramePath(); !reflect.DeepEqual(got, want) {
+			t.Errorf("CurrentFramePath after %s returned %q, want %q", after, got, want)
+		}
+	}
+	check("no switches", nil)
+	for _, frame := range []interface{}{"outer", 1, &remoteWE{client.(*remoteWebDriver), "e1"}} {
+		if err := client.SwitchFrame(frame); err != nil {
+			t.Fatalf("SwitchFrame(%v) returned error: %v", frame, err)
+		}
+	}
+	check("SwitchFrame", []string{"outer", "1", "e1"})
+	if err := client.SwitchFrameParent(); err != nil {
+		t.Fatalf("SwitchFrameParent returned error: %v", err)
+	}
+	check("SwitchFrameParent", []string{"outer", "1"})
+	if err := client.SwitchToDefaultContent(); err != nil {
+		t.Fatalf("SwitchToDefaultContent returned error: %v", err)
+	}
+	check("SwitchToDefaultContent", nil)
+	if err := client.SwitchFrameParent(); err != nil {
+		t.Fatalf("SwitchFrameParent returned error: %v", err)
+	}
+	check("SwitchFrameParent in the top-level document", nil)
+
+	navigations := []struct {
+		name string
+		fn   func() error
+	}{
+		{"Get", func() error { return client.Get("http://example.com") }},
+		{"Back", client.Back},
+		{"Forward", client.Forward},
+		{"Refresh", client.Refresh},
+	}
+	for _, nav := range navigations {
+		if err := client.SwitchFrame("outer"); err != nil {
+			t.Fatalf("SwitchFrame returned error: %v", err)
+		}
+		if err := nav.fn(); err != nil {
+			t.Fatalf("%s returned error: %v", nav.name, err)
+		}
+		check(nav.name, nil)
+	}
+}
+
+func TestWait(t *testing.T) {
+	setup()
+	defer teardown()
+
+	mux.HandleFunc("/session/123/element", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprint(w, `{"status": 7, "value": {"message": "Unable to locate element"}}`)
+	})
+
+	calls := 0
+	cond := func(wd WebDriver) (bool, error) {
+		calls++
+		if calls == 3 {
+			return true, nil
+		}
+		_, err := wd.FindElement(ById, "late")
+		return false, err
+	}
+	if err := NewWait(client, time.Second, time.Millisecond).IgnoreErrors(ErrNoSuchElement).Until(cond); err != nil {
+		t.Fatalf("Until returned error: %v", err)
+	}
+	if calls != 3 {
+		t.Errorf("Until called the condition %d times, want 3", calls)
+	}
+
+	calls = 0
+	err := NewWait(client, time.Second, time.Millisecond).IgnoreErrors(ErrStaleElement).Until(cond)
+	if !errors.Is(err, ErrNoSuchElement) || calls != 1 {
+		t.Errorf("Until without ignoring the error returned %v after %d calls, want no such element after 1", err, calls)
+	}
+
+	polls := 0
+	never := func(WebDriver) (bool, error) {
+		polls++
+		return false, nil
+	}
+	if err := NewWait(client, 100*time.Millisecond, 0).Until(never); err != ErrWaitTimeout {
+		t.Errorf("Until returned error %v, want %v", err, ErrWaitTimeout)
+	}
+	if polls > 12 {
+		t.Errorf("Until with a zero interval polled %d times in 100ms, want at most 12", polls)
+	}
+}
+
+func TestGetAttributes(t *testing.T) {
+	setupW3C()
+	defer teardown()
+
+	mux.HandleFunc("/session/123/execute", func(w http.ResponseWriter, r *http.Request) {
+		var v struct {
+			Args []interface{} `json:"args"`
+		}
+		json.NewDecoder(r.Body).Decode(&v)
+		want := []interface{}{map[string]interface{}{webElementKey: "e1", "ELEMENT": "e1"}, "id", "href"}
+		if !reflect.DeepEqual(v.Args, want) {
+			t.Errorf("Script args = %v, want %v", v.Args, want)
+		}
+		fmt.Fprint(w, `{"value": {"id": "name"}}`)
+	})
+
+	elem := &remoteWE{client.(*remoteWebDriver), "e1"}
+	attrs, err := elem.GetAttributes("id", "href")
+	if err != nil {
+		t.Fatalf("GetAttributes returned error: %v", err)
+	}
+	if want := map[string]string{"id": "name"}; !reflect.DeepEqual(attrs, want) {
+		t.Errorf("GetAttributes = %v, want %v", attrs, want)
+	}
+}
+
+func TestNewTab_Concurrent(t *testing.T) {
+	setupW3C()
+	defer teardown()
+
+	var mu sync.Mutex
+	current := "t1"
+	mux.HandleFunc("/session/123/window/new", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"value": {"handle": "t2", "type": "tab"}}`)
+	})
+	mux.HandleFunc("/session/123/window", func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		if r.Method == "GET" {
+			fmt.Fprintf(w, `{"value": %q}`, current)
+			return
+		}
+		var v map[string]string
+		json.NewDecoder(r.Body).Decode(&v)
+		current = v["handle"]
+		fmt.Fprint(w, `{"value": null}`)
+	})
+	mux.HandleFunc("/session/123/title", func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		fmt.Fprintf(w, `{"value": %q}`, current)
+	})
+
+	tab, err := client.NewTab()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var wg sync.WaitGroup
+	for _, tt := range []struct {
+		wd     WebDriver
+		window string
+	}{{client, "t1"}, {tab, "t2"}} {
+		tt := tt
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 20; i++ {
+				title, err := tt.wd.Title()
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				if title != tt.window {
+					t.Errorf("Title ran in window %q, want %q", title, tt.window)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestTabTo_InvalidMax(t *testing.T) {
+	setupW3C()
+	defer teardown()
+
+	mux.HandleFunc("/session/123/", func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("TabTo sent %s %s", r.Method, r.URL.Path)
+		fmt.Fprint(w, `{"value": false}`)
+	})
+
+	if err := client.TabTo(&remoteWE{client.(*remoteWebDriver), "e1"}, -1); err == nil {
+		t.Error("TabTo with a negative maxTabs returned no error")
+	}
+}
+
+func TestRenderedLines_Indentation(t *testing.T) {
+	setupW3C()
+	defer teardown()
+
+	mux.HandleFunc("/session/123/execute", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"value": "func main() {\n\tfmt.Println()\n  \n}\n"}`)
+	})
+
+	elem := &remoteWE{client.(*remoteWebDriver), "e1"}
+	lines, err := elem.RenderedLines()
+	if err != nil {
+		t.Fatalf("RenderedLines returned error: %v", err)
+	}
+	if want := []string{"func main() {", "\tfmt.Println()", "}"}; !reflect.DeepEqual(lines, want) {
+		t.Errorf("RenderedLines returned %q, want %q", lines, want)
+	}
+}
+
+func TestElementScripts_UnexpectedResult(t *testing.T) {
+	setupW3C()
+	defer teardown()
+
+	mux.HandleFunc("/session/123/execute", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"value": 42}`)
+	})
+	mux.HandleFunc("/session/123/element/e1/property/innerHTML", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"value": null}`)
+	})
+
+	elem := &remoteWE{client.(*remoteWebDriver), "e1"}
+	for _, tc := range []struct {
+		name string
+		call func() error
+		typ  string
+	}{
+		{"RenderedLines", func() error { _, err := elem.RenderedLines(); return err }, "float64"},
+		{"VisibilityReason", func() error { _, err := elem.VisibilityReason(); return err }, "float64"},
+		{"HasAttribute", func() error { _, err := elem.HasAttribute("id"); return err }, "float64"},
+		{"Attributes", func() error { _, err := elem.Attributes(); return err }, "float64"},
+		{"InnerHTML", func() error { _, err := elem.InnerHTML(); return err }, "<nil>"},
+	} {
+		err := tc.call()
+		if err == nil || !strings.Contains(err.Error(), tc.typ) {
+			t.Errorf("%s returned error %v, want one naming %s", tc.name, err, tc.typ)
+		}
+	}
+}
+
+func TestAttributes_NonStringValue(t *testing.T) {
+	setupW3C()
+	defer teardown()
+
+	mux.HandleFunc("/session/123/execute", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"value": {"id": "a", "hidden": true}}`)
+	})
+
+	elem := &remoteWE{client.(*remoteWebDriver), "e1"}
+	if _, err := elem.GetAttributes("id", "hidden"); err == nil || !strings.Contains(err.Error(), "bool") {
+		t.Errorf("GetAttributes returned error %v, want one naming bool", err)
+	}
+}
+
+// websocketServer serves a single WebSocket connection with serve and returns
+// its ws URL.
+func websocketServer(t *testing.T, serve func(conn net.Conn, br *bufio.Reader)) (string, func()) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		sum := sha1.Sum([]byte(r.Header.Get("Sec-WebSocket-Key") + websocketGUID))
+		conn, brw, err := w.(http.Hijacker).Hijack()
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		defer conn.Close()
+		fmt.Fprintf(conn, "HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\nSec-WebSocket-Accept: %s\r\n\r\n",
+			base64.StdEncoding.EncodeToString(sum[:]))
+		serve(conn, brw.Reader)
+	}))
+	return "ws" + strings.TrimPrefix(server.URL, "http") + "/devtools/page/1", server.Close
+}
+
+// readClientFrame reads a frame, which the client must have masked.
+func readClientFrame(t *testing.T, br *bufio.Reader) (op byte, payload []byte) {
+	var h [2]byte
+	if _, err := io.ReadFull(br, h[:]); err != nil {
+		t.Errorf("Reading frame: %v", err)
+		return 0, nil
+	}
+	if h[1]&0x80 == 0 {
+		t.Error("Client sent an unmasked frame")
+	}
+	n := uint64(h[1] & 0x7f)
+	switch n {
+	case 126:
+		var ext [2]byte
+		io.ReadFull(br, ext[:])
+		n = uint64(binary.BigEndian.Uint16(ext[:]))
+	case 127:
+		var ext [8]byte
+		io.ReadFull(br, ext[:])
+		n = binary.BigEndian.Uint64(ext[:])
+	}
+	var mask [4]byte
+	io.ReadFull(br, mask[:])
+	payload = make([]byte, n)
+	io.ReadFull(br, payload)
+	for i := range payload {
+		payload[i] ^= mask[i%4]
+	}
+	return h[0] & 0x0f, payload
+}
+
+// writeServerFrame writes an unmasked frame.
+func writeServerFrame(conn net.Conn, fin bool, op byte, payload []byte) {
+	frame := []byte{op}
+	if fin {
+		frame[0] |= 0x80
+	}
+	switch n := len(payload); {
+	case n < 126:
+		frame = append(frame, byte(n))
+	case n <= 0xffff:
+		frame = append(frame, 126, byte(n>>8), byte(n))
+	default:
+		var ext [8]byte
+		binary.BigEndian.PutUint64(ext[:], uint64(n))
+		frame = append(append(frame, 127), ext[:]...)
+	}
+	conn.Write(append(frame, payload...))
+}
+
+func TestDevtoolsConn(t *testing.T) {
+	sizes := []int{10, 200, 70000}
+	wsURL, stop := websocketServer(t, func(conn net.Conn, br *bufio.Reader) {
+		for range sizes {
+			op, data := readClientFrame(t, br)
+			if op != wsText {
+				t.Errorf("Got opcode %d, want %d", op, wsText)
+			}
+			var msg devtoolsMessage
+			if err := json.Unmarshal(data, &msg); err != nil {
+				t.Error(err)
+				return
+			}
+
+			writeServerFrame(conn, true, wsPing, []byte("ping"))
+			if op, data := readClientFrame(t, br); op != wsPong || string(data) != "ping" {
+				t.Errorf("Got opcode %d %q after a ping, want a pong", op, data)
+			}
+			writeServerFrame(conn, true, wsText, []byte(`{"method": "Page.loadEventFired", "params": {}}`))
+
+			// The reply is fragmented, with a ping in between.
+			reply, _ := json.Marshal(devtoolsMessage{ID: msg.ID, Result: msg.Params})
+			half := len(reply) / 2
+			writeServerFrame(conn, false, wsText, reply[:half])
+			writeServerFrame(conn, true, wsPing, nil)
+			readClientFrame(t, br)
+			writeServerFrame(conn, true, 0, reply[half:])
+		}
+		writeServerFrame(conn, true, wsClose, nil)
+	})
+	defer stop()
+
+	c, err := dialDevtools(wsURL)
+	if err != nil {
+		t.Fatalf("dialDevtools returned error: %v", err)
+	}
+	defer c.Close()
+
+	for _, size := range sizes {
+		params := map[string]string{"text": strings.Repeat("x", size)}
+		result, err := c.call("Echo", params)
+		if err != nil {
+			t.Fatalf("call with %d bytes returned error: %v", size, err)
+		}
+		var got map[string]string
+		if err := json.Unmarshal(result, &got); err != nil || !reflect.DeepEqual(got, params) {
+			t.Errorf("call with %d bytes returned %.50s, %v, want the params", size, result, err)
+		}
+		if event := <-c.events; event.Method != "Page.loadEventFired" {
+			t.Errorf("Got event %q, want %q", event.Method, "Page.loadEventFired")
+		}
+	}
+
+	// The server closed the connection.
+	if _, ok := <-c.events; ok {
+		t.Error("Events not closed after the connection was closed")
+	}
+	if _, err := c.call("Echo", nil); err == nil {
+		t.Error("call returned no error on a closed connection")
+	}
+}
+
+func TestDevtoolsConn_CloseWithPendingCall(t *testing.T) {
+	wsURL, stop := websocketServer(t, func(conn net.Conn, br *bufio.Reader) {
+		readClientFrame(t, br)
+		writeServerFrame(conn, true, wsClose, nil)
+	})
+	defer stop()
+
+	c, err := dialDevtools(wsURL)
+	if err != nil {
+		t.Fatalf("dialDevtools returned error: %v", err)
+	}
+	defer c.Close()
+
+	if _, err := c.call("Page.enable", nil); err == nil {
+		t.Error("call returned no error when the connection closed before its reply")
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.pending) != 0 {
+		t.Errorf("%d calls still pending after the connection closed", len(c.pending))
+	}
+}
+
+func TestDevtoolsConn_WriteError(t *testing.T) {
+	client, server := net.Pipe()
+	server.Close()
+	c := &devtoolsConn{conn: client, pending: make(map[int]chan devtoolsMessage)}
+
+	if _, err := c.call("Page.enable", nil); err == nil {
+		t.Fatal("call returned no error when the write failed")
+	}
+	if len(c.pending) != 0 {
+		t.Errorf("%d calls still pending after the write failed", len(c.pending))
+	}
+}
+
+func TestDialDevtools_BadHandshake(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "not a websocket")
+	}))
+	defer server.Close()
+
+	if _, err := dialDevtools("ws" + strings.TrimPrefix(server.URL, "http") + "/devtools/page/1"); err == nil {
+		t.Error("dialDevtools returned no error for a plain HTTP reply")
+	}
+}
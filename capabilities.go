@@ -0,0 +1,247 @@
+package selenium
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// NewCapabilities returns empty capabilities to be filled in with the typed
+// setters, e.g.
+//
+//	caps := NewCapabilities().Browser("chrome").Platform("LINUX")
+func NewCapabilities() Capabilities {
+	return make(Capabilities)
+}
+
+// Browser sets the browser name, e.g. "chrome" or "firefox".
+func (c Capabilities) Browser(name string) Capabilities {
+	c["browserName"] = name
+	return c
+}
+
+// Version sets the browser version.
+func (c Capabilities) Version(version string) Capabilities {
+	c["version"] = version
+	return c
+}
+
+// Platform sets the platform, e.g. "LINUX", "MAC", "WINDOWS" or "ANY".
+func (c Capabilities) Platform(platform string) Capabilities {
+	c["platform"] = platform
+	return c
+}
+
+// AcceptInsecureCerts sets whether the browser trusts invalid and
+// self-signed TLS certificates.
+func (c Capabilities) AcceptInsecureCerts(accept bool) Capabilities {
+	c["acceptInsecureCerts"] = accept
+	return c
+}
+
+/* Page load strategies. */
+const (
+	// PageLoadNormal waits for the load event.
+	PageLoadNormal = "normal"
+	// PageLoadEager waits until the DOM is interactive (DOMContentLoaded),
+	// which suits single-page apps and pages with long-polling resources.
+	PageLoadEager = "eager"
+	// PageLoadNone returns as soon as the navigation starts.
+	PageLoadNone = "none"
+)
+
+// PageLoadStrategy sets when navigation commands return, one of the page
+// load strategy constants.
+func (c Capabilities) PageLoadStrategy(strategy string) Capabilities {
+	c["pageLoadStrategy"] = strategy
+	return c
+}
+
+// GetPageLoadStrategy returns the page load strategy, empty if it isn't set.
+// Read it from WebDriver.Capabilities for the one the server negotiated.
+func (c Capabilities) GetPageLoadStrategy() string {
+	strategy, _ := c["pageLoadStrategy"].(string)
+	return strategy
+}
+
+// SessionInfo are the capabilities of a session, as returned by
+// WebDriver.SessionCapabilities.
+type SessionInfo struct {
+	BrowserName         string
+	BrowserVersion      string
+	PlatformName        string
+	AcceptInsecureCerts bool
+	PageLoadStrategy    string
+	// Raw holds all the capabilities the server returned.
+	Raw Capabilities
+}
+
+// newSessionInfo reads the W3C capability names, falling back to the JSON
+// Wire ones.
+func newSessionInfo(c Capabilities) *SessionInfo {
+	str := func(keys ...string) string {
+		for _, key := range keys {
+			if v, ok := c[key].(string); ok && v != "" {
+				return v
+			}
+		}
+		return ""
+	}
+	info := &SessionInfo{
+		BrowserName:      str("browserName"),
+		BrowserVersion:   str("browserVersion", "version"),
+		PlatformName:     str("platformName", "platform"),
+		PageLoadStrategy: c.GetPageLoadStrategy(),
+		Raw:              c,
+	}
+	info.AcceptInsecureCerts, _ = c["acceptInsecureCerts"].(bool)
+	return info
+}
+
+/* Proxy types. */
+const (
+	ProxyDirect     = "direct"
+	ProxyManual     = "manual"
+	ProxyPAC        = "pac"
+	ProxyAutodetect = "autodetect"
+	ProxySystem     = "system"
+)
+
+// Proxy configures the browser's proxy.
+type Proxy struct {
+	// Type is one of the proxy type constants.
+	Type       string
+	HTTPProxy  string
+	SSLProxy   string
+	SOCKSProxy string
+	// SOCKSVersion is 4 or 5, required with SOCKSProxy.
+	SOCKSVersion int
+	NoProxy      []string
+	// ProxyAutoconfigURL is the PAC file URL, required for ProxyPAC.
+	ProxyAutoconfigURL string
+}
+
+// Validate reports whether p is complete for its type.
+func (p Proxy) Validate() error {
+	switch p.Type {
+	case ProxyManual:
+		if p.HTTPProxy == "" && p.SSLProxy == "" && p.SOCKSProxy == "" {
+			return errors.New("manual proxy needs an HTTP, SSL or SOCKS proxy")
+		}
+		if p.SOCKSProxy != "" && p.SOCKSVersion != 4 && p.SOCKSVersion != 5 {
+			return fmt.Errorf("invalid SOCKS version %d", p.SOCKSVersion)
+		}
+	case ProxyPAC:
+		if p.ProxyAutoconfigURL == "" {
+			return errors.New("pac proxy needs an autoconfig URL")
+		}
+	case ProxyDirect, ProxyAutodetect, ProxySystem:
+	default:
+		return fmt.Errorf("invalid proxy type %q", p.Type)
+	}
+	return nil
+}
+
+func (p Proxy) capability() map[string]interface{} {
+	proxy := map[string]interface{}{"proxyType": p.Type}
+	if p.HTTPProxy != "" {
+		proxy["httpProxy"] = p.HTTPProxy
+	}
+	if p.SSLProxy != "" {
+		proxy["sslProxy"] = p.SSLProxy
+	}
+	if p.SOCKSProxy != "" {
+		proxy["socksProxy"] = p.SOCKSProxy
+		proxy["socksVersion"] = p.SOCKSVersion
+	}
+	if len(p.NoProxy) > 0 {
+		proxy["noProxy"] = p.NoProxy
+	}
+	if p.ProxyAutoconfigURL != "" {
+		proxy["proxyAutoconfigUrl"] = p.ProxyAutoconfigURL
+	}
+	return proxy
+}
+
+// Proxy sets the browser's proxy without validating it, see SetProxy.
+func (c Capabilities) Proxy(p Proxy) Capabilities {
+	c["proxy"] = p.capability()
+	return c
+}
+
+// SetProxy validates p and sets it as the browser's proxy.
+func (c Capabilities) SetProxy(p Proxy) error {
+	if err := p.Validate(); err != nil {
+		return err
+	}
+	c.Proxy(p)
+	return nil
+}
+
+/* Log levels for LoggingPrefs. */
+const (
+	LogOff     = "OFF"
+	LogSevere  = "SEVERE"
+	LogWarning = "WARNING"
+	LogInfo    = "INFO"
+	LogDebug   = "DEBUG"
+	LogAll     = "ALL"
+)
+
+// LoggingPrefs maps a log type ("browser", "driver", "performance", ...) to
+// the level to collect.
+type LoggingPrefs map[string]string
+
+// Logging sets which logs the browser collects.
+func (c Capabilities) Logging(prefs LoggingPrefs) Capabilities {
+	c["loggingPrefs"] = map[string]string(prefs)
+	return c
+}
+
+// marshalOptions encodes v, a browser options struct, together with the
+// extra options it has no field for.
+func marshalOptions(v interface{}, extra map[string]interface{}) ([]byte, error) {
+	data, err := json.Marshal(v)
+	if err != nil || len(extra) == 0 {
+		return data, err
+	}
+	all := make(map[string]interface{}, len(extra))
+	for k, e := range extra {
+		all[k] = e
+	}
+	// The fields win over extra options of the same name.
+	if err := json.Unmarshal(data, &all); err != nil {
+		return nil, err
+	}
+	return json.Marshal(all)
+}
+
+// unmarshalOptions decodes data into v, a pointer to a browser options
+// struct, and returns the options it has no field for.
+func unmarshalOptions(data []byte, v interface{}) (map[string]interface{}, error) {
+	if err := json.Unmarshal(data, v); err != nil {
+		return nil, err
+	}
+	var extra map[string]interface{}
+	if err := json.Unmarshal(data, &extra); err != nil {
+		return nil, err
+	}
+	t := reflect.TypeOf(v).Elem()
+	for i := 0; i < t.NumField(); i++ {
+		delete(extra, strings.Split(t.Field(i).Tag.Get("json"), ",")[0])
+	}
+	if len(extra) == 0 {
+		return nil, nil
+	}
+	return extra, nil
+}
+
+// convertOptions sets the browser options o from v, browser options of
+// another type such as a map decoded from JSON. Invalid options are ignored.
+func convertOptions(v interface{}, o interface{}) {
+	if data, err := json.Marshal(v); err == nil {
+		json.Unmarshal(data, o)
+	}
+}
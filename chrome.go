@@ -0,0 +1,397 @@
+package selenium
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+)
+
+// ErrNotSupported is returned when a command is not available for the
+// session's browser or driver.
+var ErrNotSupported = errors.New("not supported by this driver")
+
+// ChromeOptions are the chromedriver specific capabilities, see
+// https://chromedriver.chromium.org/capabilities. Attach them with
+// Capabilities.SetChromeOptions.
+type ChromeOptions struct {
+	Args   []string `json:"args,omitempty"`
+	Binary string   `json:"binary,omitempty"`
+	// Extensions are base64 encoded .crx files.
+	Extensions []string               `json:"extensions,omitempty"`
+	Prefs      map[string]interface{} `json:"prefs,omitempty"`
+	// Extra are the options without a field, e.g. "mobileEmulation".
+	Extra map[string]interface{} `json:"-"`
+}
+
+func (o *ChromeOptions) MarshalJSON() ([]byte, error) {
+	type options ChromeOptions
+	return marshalOptions((*options)(o), o.Extra)
+}
+
+func (o *ChromeOptions) UnmarshalJSON(data []byte) error {
+	type options ChromeOptions
+	extra, err := unmarshalOptions(data, (*options)(o))
+	o.Extra = extra
+	return err
+}
+
+// AddArgument adds a command-line argument, e.g. "--headless".
+func (o *ChromeOptions) AddArgument(arg string) *ChromeOptions {
+	o.Args = append(o.Args, arg)
+	return o
+}
+
+// SetBinary sets the path of the Chrome binary.
+func (o *ChromeOptions) SetBinary(path string) *ChromeOptions {
+	o.Binary = path
+	return o
+}
+
+// AddExtension installs the packed (.crx) extension.
+func (o *ChromeOptions) AddExtension(crx []byte) *ChromeOptions {
+	o.Extensions = append(o.Extensions, base64.StdEncoding.EncodeToString(crx))
+	return o
+}
+
+// SetPrefs sets user preferences, e.g. "download.default_directory".
+func (o *ChromeOptions) SetPrefs(prefs map[string]interface{}) *ChromeOptions {
+	o.Prefs = prefs
+	return o
+}
+
+// SetChromeOptions sets the Chrome specific capabilities.
+func (c Capabilities) SetChromeOptions(o *ChromeOptions) Capabilities {
+	c["goog:chromeOptions"] = o
+	return c
+}
+
+// ChromeOptions returns the options set with SetChromeOptions, setting empty
+// ones first if there are none, so that they can be changed in place.
+// Options set otherwise, e.g. as a map decoded from JSON, are converted.
+func (c Capabilities) ChromeOptions() *ChromeOptions {
+	o, ok := c["goog:chromeOptions"].(*ChromeOptions)
+	if !ok {
+		o = new(ChromeOptions)
+		if v, set := c["goog:chromeOptions"]; set {
+			convertOptions(v, o)
+		}
+		c.SetChromeOptions(o)
+	}
+	return o
+}
+
+// HeadlessChrome returns capabilities for Chrome without a display, e.g. on
+// CI. Add to them with ChromeOptions:
+//
+//	caps := HeadlessChrome()
+//	caps.ChromeOptions().AddArgument("--window-size=1280,1024")
+func HeadlessChrome() Capabilities {
+	caps := NewCapabilities().Browser("chrome")
+	caps.ChromeOptions().AddArgument("--headless").AddArgument("--disable-gpu")
+	return caps
+}
+
+func (wd *remoteWebDriver) isChromium() bool {
+	name := wd.browserName()
+	return name == "chrome" || name == "chromium"
+}
+
+// cdp runs a Chrome DevTools Protocol command through chromedriver and
+// returns the raw result.
+func (wd *remoteWebDriver) cdp(name, cmd string, params map[string]interface{}) (json.RawMessage, error) {
+	if !wd.isChromium() {
+		return nil, ErrNotSupported
+	}
+	if params == nil {
+		params = map[string]interface{}{}
+	}
+	data, err := json.Marshal(map[string]interface{}{"cmd": cmd, "params": params})
+	if err != nil {
+		return nil, err
+	}
+	r, err := wd.send(name, "POST", wd.url("/session/%s/chromium/send_command_and_get_result", wd.sessionID()), data)
+	if err != nil {
+		return nil, err
+	}
+	return r.Value, nil
+}
+
+func (wd *remoteWebDriver) ExecuteCDPCommand(cmd string, params map[string]interface{}) (interface{}, error) {
+	raw, err := wd.cdp("ExecuteCDPCommand", cmd, params)
+	if err != nil {
+		return nil, err
+	}
+	var v interface{}
+	if err := json.Unmarshal(raw, &v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// GetAllCookiesCDP reads the cookies of every domain with the DevTools
+// Network.getAllCookies command, while GetCookies only returns the cookies
+// visible to the current page. Session cookies have no Expiry.
+func (wd *remoteWebDriver) GetAllCookiesCDP() ([]Cookie, error) {
+	raw, err := wd.cdp("GetAllCookiesCDP", "Network.getAllCookies", nil)
+	if err != nil {
+		return nil, err
+	}
+	var res struct {
+		Cookies []struct {
+			Name     string  `json:"name"`
+			Value    string  `json:"value"`
+			Domain   string  `json:"domain"`
+			Path     string  `json:"path"`
+			Expires  float64 `json:"expires"`
+			HttpOnly bool    `json:"httpOnly"`
+			Secure   bool    `json:"secure"`
+			Session  bool    `json:"session"`
+			SameSite string  `json:"sameSite"`
+		} `json:"cookies"`
+	}
+	if err := json.Unmarshal(raw, &res); err != nil {
+		return nil, err
+	}
+	cookies := make([]Cookie, len(res.Cookies))
+	for i, c := range res.Cookies {
+		cookies[i] = Cookie{
+			Name:     c.Name,
+			Value:    c.Value,
+			Path:     c.Path,
+			Domain:   c.Domain,
+			Secure:   c.Secure,
+			HttpOnly: c.HttpOnly,
+			SameSite: c.SameSite,
+		}
+		if !c.Session && c.Expires > 0 {
+			cookies[i].Expiry = uint(c.Expires)
+		}
+	}
+	return cookies, nil
+}
+
+// LocationOption configures SetLocation.
+type LocationOption func(*locationOptions)
+
+type locationOptions struct {
+	grantPermission bool
+}
+
+// GrantLocationPermission sets whether SetLocation grants the geolocation
+// permission on Chrome, which it does by default.
+func GrantLocationPermission(grant bool) LocationOption {
+	return func(o *locationOptions) {
+		o.grantPermission = grant
+	}
+}
+
+func (wd *remoteWebDriver) SetLocation(loc Location, opts ...LocationOption) error {
+	if !wd.isChromium() {
+		params := map[string]interface{}{
+			"location": map[string]float64{
+				"latitude":  loc.Latitude,
+				"longitude": loc.Longitude,
+				"altitude":  loc.Altitude,
+			},
+		}
+		return wd.voidCommand("SetLocation", "/session/%s/location", params)
+	}
+
+	o := locationOptions{grantPermission: true}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.grantPermission {
+		origin, err := wd.ExecuteScript("return window.location.origin;", nil)
+		if err != nil {
+			return err
+		}
+		// Opaque origins, e.g. of about:blank, can't be granted permissions.
+		if origin, ok := origin.(string); ok && origin != "null" {
+			params := map[string]interface{}{"permissions": []string{"geolocation"}, "origin": origin}
+			if _, err := wd.cdp("SetLocation", "Browser.grantPermissions", params); err != nil {
+				return err
+			}
+		}
+	}
+	_, err := wd.cdp("SetLocation", "Emulation.setGeolocationOverride", map[string]interface{}{
+		"latitude":  loc.Latitude,
+		"longitude": loc.Longitude,
+		"altitude":  loc.Altitude,
+		"accuracy":  1,
+	})
+	return err
+}
+
+// NetworkConditions emulates a network connection in Chrome. Throughputs are
+// in bytes per second, zero for no limit.
+type NetworkConditions struct {
+	Offline            bool `json:"offline"`
+	Latency            int  `json:"latency"` // milliseconds
+	DownloadThroughput int  `json:"download_throughput"`
+	UploadThroughput   int  `json:"upload_throughput"`
+}
+
+func (wd *remoteWebDriver) SetNetworkConditions(conditions NetworkConditions) error {
+	if !wd.isChromium() {
+		return ErrNotSupported
+	}
+	params := map[string]NetworkConditions{"network_conditions": conditions}
+	return wd.voidCommand("SetNetworkConditions", "/session/%s/chromium/network_conditions", params)
+}
+
+func (wd *remoteWebDriver) GetNetworkConditions() (*NetworkConditions, error) {
+	if !wd.isChromium() {
+		return nil, ErrNotSupported
+	}
+	r, err := wd.send("GetNetworkConditions", "GET", wd.url("/session/%s/chromium/network_conditions", wd.sessionID()), nil)
+	if err != nil {
+		return nil, err
+	}
+	var conditions NetworkConditions
+	if err := r.readValue(&conditions); err != nil {
+		return nil, err
+	}
+	return &conditions, nil
+}
+
+func (wd *remoteWebDriver) PerformanceMetrics() (map[string]float64, error) {
+	if _, err := wd.cdp("PerformanceMetrics", "Performance.enable", nil); err != nil {
+		return nil, err
+	}
+	raw, err := wd.cdp("PerformanceMetrics", "Performance.getMetrics", nil)
+	if err != nil {
+		return nil, err
+	}
+	var res struct {
+		Metrics []struct {
+			Name  string  `json:"name"`
+			Value float64 `json:"value"`
+		} `json:"metrics"`
+	}
+	if err := json.Unmarshal(raw, &res); err != nil {
+		return nil, err
+	}
+	metrics := make(map[string]float64, len(res.Metrics))
+	for _, m := range res.Metrics {
+		metrics[m.Name] = m.Value
+	}
+	return metrics, nil
+}
+
+func (wd *remoteWebDriver) CaptureSnapshotMHTML() (string, error) {
+	raw, err := wd.cdp("CaptureSnapshotMHTML", "Page.captureSnapshot", map[string]interface{}{"format": "mhtml"})
+	if err != nil {
+		return "", err
+	}
+	var res struct {
+		Data string `json:"data"`
+	}
+	if err := json.Unmarshal(raw, &res); err != nil {
+		return "", err
+	}
+	return res.Data, nil
+}
+
+func (wd *remoteWebDriver) SaveMHTML(path string) error {
+	mhtml, err := wd.CaptureSnapshotMHTML()
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, []byte(mhtml), 0644)
+}
+
+// InterceptedRequest is a browser request paused by InterceptRequests.
+type InterceptedRequest struct {
+	URL      string
+	Method   string
+	Headers  map[string]string
+	PostData string
+}
+
+// InterceptResponse tells InterceptRequests how to answer a request. If
+// Status is zero the request continues to the network, with RequestHeaders
+// added to (or replacing) its headers; otherwise the browser receives
+// Status, Headers and Body without contacting the server.
+type InterceptResponse struct {
+	Status         int
+	Headers        map[string]string
+	Body           []byte
+	RequestHeaders map[string]string
+}
+
+func (wd *remoteWebDriver) InterceptRequests(pattern string, handler func(InterceptedRequest) InterceptResponse) (stop func(), err error) {
+	conn, err := wd.devtools()
+	if err != nil {
+		return nil, err
+	}
+	patterns := []map[string]string{{"urlPattern": pattern}}
+	if _, err := conn.call("Fetch.enable", map[string]interface{}{"patterns": patterns}); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	go func() {
+		for ev := range conn.events {
+			if ev.Method != "Fetch.requestPaused" {
+				continue
+			}
+			// Answer in the background: results arrive on the goroutine
+			// that delivers events, so waiting here would deadlock.
+			go interceptRequest(conn, ev.Params, handler)
+		}
+	}()
+
+	return func() {
+		conn.call("Fetch.disable", nil)
+		conn.Close()
+	}, nil
+}
+
+func interceptRequest(conn *devtoolsConn, params json.RawMessage, handler func(InterceptedRequest) InterceptResponse) {
+	var paused struct {
+		RequestID string `json:"requestId"`
+		Request   struct {
+			URL      string            `json:"url"`
+			Method   string            `json:"method"`
+			Headers  map[string]string `json:"headers"`
+			PostData string            `json:"postData"`
+		} `json:"request"`
+	}
+	if json.Unmarshal(params, &paused) != nil {
+		return
+	}
+	req := paused.Request
+	res := handler(InterceptedRequest{URL: req.URL, Method: req.Method, Headers: req.Headers, PostData: req.PostData})
+
+	if res.Status == 0 {
+		continued := map[string]interface{}{"requestId": paused.RequestID}
+		if len(res.RequestHeaders) > 0 {
+			headers := make(map[string]string)
+			for k, v := range req.Headers {
+				headers[k] = v
+			}
+			for k, v := range res.RequestHeaders {
+				headers[k] = v
+			}
+			continued["headers"] = headerEntries(headers)
+		}
+		conn.call("Fetch.continueRequest", continued)
+		return
+	}
+	conn.call("Fetch.fulfillRequest", map[string]interface{}{
+		"requestId":       paused.RequestID,
+		"responseCode":    res.Status,
+		"responseHeaders": headerEntries(res.Headers),
+		"body":            base64.StdEncoding.EncodeToString(res.Body),
+	})
+}
+
+func headerEntries(headers map[string]string) []map[string]string {
+	entries := make([]map[string]string, 0, len(headers))
+	for name, value := range headers {
+		entries = append(entries, map[string]string{"name": name, "value": value})
+	}
+	return entries
+}
@@ -0,0 +1,105 @@
+package selenium
+
+import (
+	"fmt"
+	"image/color"
+	"strconv"
+	"strings"
+)
+
+func (elem *remoteWE) CSSColor(name string) (color.RGBA, error) {
+	v, err := elem.CSSProperty(name)
+	if err != nil {
+		return color.RGBA{}, err
+	}
+	return parseCSSColor(v)
+}
+
+func (elem *remoteWE) CSSPixels(name string) (float64, error) {
+	v, err := elem.CSSProperty(name)
+	if err != nil {
+		return 0, err
+	}
+	return parseCSSPixels(v)
+}
+
+// parseCSSColor parses the forms browsers report computed colors in,
+// rgb(), rgba() and "transparent", as well as hex colors.
+func parseCSSColor(v string) (color.RGBA, error) {
+	s := strings.ToLower(strings.TrimSpace(v))
+	switch {
+	case s == "transparent":
+		return color.RGBA{}, nil
+	case strings.HasPrefix(s, "#"):
+		return parseHexColor(v, s[1:])
+	case strings.HasPrefix(s, "rgb(") || strings.HasPrefix(s, "rgba("):
+	default:
+		return color.RGBA{}, fmt.Errorf("unsupported CSS color %q", v)
+	}
+
+	args := s[strings.Index(s, "(")+1:]
+	if !strings.HasSuffix(args, ")") {
+		return color.RGBA{}, fmt.Errorf("bad CSS color %q", v)
+	}
+	// Both "rgba(1, 2, 3, 0.5)" and "rgb(1 2 3 / 50%)".
+	fields := strings.FieldsFunc(strings.TrimSuffix(args, ")"), func(r rune) bool {
+		return r == ',' || r == '/' || r == ' '
+	})
+	if len(fields) != 3 && len(fields) != 4 {
+		return color.RGBA{}, fmt.Errorf("bad CSS color %q", v)
+	}
+	var channels [4]float64
+	channels[3] = 1
+	for i, f := range fields {
+		percent := strings.HasSuffix(f, "%")
+		n, err := strconv.ParseFloat(strings.TrimSuffix(f, "%"), 64)
+		if err != nil {
+			return color.RGBA{}, fmt.Errorf("bad CSS color %q", v)
+		}
+		switch {
+		case percent:
+			n /= 100
+		case i < 3:
+			n /= 255
+		}
+		if n < 0 || n > 1 {
+			return color.RGBA{}, fmt.Errorf("bad CSS color %q", v)
+		}
+		channels[i] = n
+	}
+	// color.RGBA is alpha-premultiplied.
+	a := channels[3]
+	return color.RGBA{
+		R: uint8(channels[0]*a*255 + 0.5),
+		G: uint8(channels[1]*a*255 + 0.5),
+		B: uint8(channels[2]*a*255 + 0.5),
+		A: uint8(a*255 + 0.5),
+	}, nil
+}
+
+func parseHexColor(v, hex string) (color.RGBA, error) {
+	if len(hex) == 3 {
+		hex = string([]byte{hex[0], hex[0], hex[1], hex[1], hex[2], hex[2]})
+	}
+	n, err := strconv.ParseUint(hex, 16, 32)
+	if len(hex) != 6 || err != nil {
+		return color.RGBA{}, fmt.Errorf("bad CSS color %q", v)
+	}
+	return color.RGBA{R: uint8(n >> 16), G: uint8(n >> 8), B: uint8(n), A: 255}, nil
+}
+
+// parseCSSPixels parses a length in px, as browsers report computed lengths.
+func parseCSSPixels(v string) (float64, error) {
+	s := strings.TrimSpace(v)
+	if s == "0" {
+		return 0, nil
+	}
+	if !strings.HasSuffix(s, "px") {
+		return 0, fmt.Errorf("CSS value %q is not in pixels", v)
+	}
+	n, err := strconv.ParseFloat(strings.TrimSuffix(s, "px"), 64)
+	if err != nil {
+		return 0, fmt.Errorf("bad CSS length %q", v)
+	}
+	return n, nil
+}
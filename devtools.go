@@ -0,0 +1,303 @@
+package selenium
+
+import (
+	"bufio"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// devtoolsConn is a minimal Chrome DevTools Protocol client speaking to a
+// page target over a WebSocket. Unlike commands sent through chromedriver, it
+// receives protocol events, which request interception depends on.
+type devtoolsConn struct {
+	conn net.Conn
+	br   *bufio.Reader
+
+	writeMu sync.Mutex
+
+	mu      sync.Mutex
+	nextID  int
+	pending map[int]chan devtoolsMessage
+	err     error
+
+	events chan devtoolsMessage
+}
+
+type devtoolsMessage struct {
+	ID     int             `json:"id,omitempty"`
+	Method string          `json:"method,omitempty"`
+	Params json.RawMessage `json:"params,omitempty"`
+	Result json.RawMessage `json:"result,omitempty"`
+	Error  *struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	} `json:"error,omitempty"`
+}
+
+const websocketGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+/* WebSocket opcodes */
+const (
+	wsText  = 0x1
+	wsClose = 0x8
+	wsPing  = 0x9
+	wsPong  = 0xa
+)
+
+// devtoolsDialTimeout bounds connecting to the DevTools endpoint, including
+// the WebSocket handshake.
+const devtoolsDialTimeout = 10 * time.Second
+
+func dialDevtools(wsURL string) (*devtoolsConn, error) {
+	u, err := url.Parse(wsURL)
+	if err != nil {
+		return nil, err
+	}
+	conn, err := net.DialTimeout("tcp", u.Host, devtoolsDialTimeout)
+	if err != nil {
+		return nil, err
+	}
+	if err := conn.SetDeadline(time.Now().Add(devtoolsDialTimeout)); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	var nonce [16]byte
+	if _, err := rand.Read(nonce[:]); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	key := base64.StdEncoding.EncodeToString(nonce[:])
+	req, err := http.NewRequest("GET", "http://"+u.Host+u.RequestURI(), nil)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	req.Header.Set("Upgrade", "websocket")
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Sec-WebSocket-Key", key)
+	req.Header.Set("Sec-WebSocket-Version", "13")
+	if err := req.Write(conn); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	br := bufio.NewReader(conn)
+	res, err := http.ReadResponse(br, req)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	res.Body.Close()
+	sum := sha1.Sum([]byte(key + websocketGUID))
+	if res.StatusCode != http.StatusSwitchingProtocols ||
+		res.Header.Get("Sec-WebSocket-Accept") != base64.StdEncoding.EncodeToString(sum[:]) {
+		conn.Close()
+		return nil, fmt.Errorf("devtools: bad websocket handshake: %s", res.Status)
+	}
+	if err := conn.SetDeadline(time.Time{}); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	c := &devtoolsConn{
+		conn:    conn,
+		br:      br,
+		pending: make(map[int]chan devtoolsMessage),
+		events:  make(chan devtoolsMessage, 64),
+	}
+	go c.readLoop()
+	return c, nil
+}
+
+// call sends a command and waits for its result.
+func (c *devtoolsConn) call(method string, params interface{}) (json.RawMessage, error) {
+	c.mu.Lock()
+	if c.err != nil {
+		c.mu.Unlock()
+		return nil, c.err
+	}
+	c.nextID++
+	id := c.nextID
+	ch := make(chan devtoolsMessage, 1)
+	c.pending[id] = ch
+	c.mu.Unlock()
+
+	data, err := json.Marshal(map[string]interface{}{"id": id, "method": method, "params": params})
+	if err == nil {
+		err = c.writeFrame(wsText, data)
+	}
+	if err != nil {
+		c.mu.Lock()
+		delete(c.pending, id)
+		c.mu.Unlock()
+		return nil, err
+	}
+
+	msg, ok := <-ch
+	if !ok {
+		return nil, c.err
+	}
+	if msg.Error != nil {
+		return nil, fmt.Errorf("devtools: %s: %s", method, msg.Error.Message)
+	}
+	return msg.Result, nil
+}
+
+func (c *devtoolsConn) Close() error {
+	c.writeFrame(wsClose, nil)
+	return c.conn.Close()
+}
+
+func (c *devtoolsConn) readLoop() {
+	var err error
+	for {
+		var data []byte
+		if data, err = c.readMessage(); err != nil {
+			break
+		}
+		var msg devtoolsMessage
+		if json.Unmarshal(data, &msg) != nil {
+			continue
+		}
+		if msg.ID == 0 {
+			c.events <- msg
+			continue
+		}
+		c.mu.Lock()
+		ch := c.pending[msg.ID]
+		delete(c.pending, msg.ID)
+		c.mu.Unlock()
+		if ch != nil {
+			ch <- msg
+		}
+	}
+
+	c.mu.Lock()
+	c.err = err
+	for id, ch := range c.pending {
+		close(ch)
+		delete(c.pending, id)
+	}
+	c.mu.Unlock()
+	close(c.events)
+}
+
+func (c *devtoolsConn) readMessage() ([]byte, error) {
+	var msg []byte
+	for {
+		var h [2]byte
+		if _, err := io.ReadFull(c.br, h[:]); err != nil {
+			return nil, err
+		}
+		fin, op := h[0]&0x80 != 0, h[0]&0x0f
+		n := uint64(h[1] & 0x7f)
+		switch n {
+		case 126:
+			var ext [2]byte
+			if _, err := io.ReadFull(c.br, ext[:]); err != nil {
+				return nil, err
+			}
+			n = uint64(binary.BigEndian.Uint16(ext[:]))
+		case 127:
+			var ext [8]byte
+			if _, err := io.ReadFull(c.br, ext[:]); err != nil {
+				return nil, err
+			}
+			n = binary.BigEndian.Uint64(ext[:])
+		}
+		var mask [4]byte
+		masked := h[1]&0x80 != 0
+		if masked {
+			if _, err := io.ReadFull(c.br, mask[:]); err != nil {
+				return nil, err
+			}
+		}
+		payload := make([]byte, n)
+		if _, err := io.ReadFull(c.br, payload); err != nil {
+			return nil, err
+		}
+		if masked {
+			for i := range payload {
+				payload[i] ^= mask[i%4]
+			}
+		}
+
+		switch op {
+		case wsClose:
+			return nil, io.EOF
+		case wsPing:
+			if err := c.writeFrame(wsPong, payload); err != nil {
+				return nil, err
+			}
+			continue
+		case wsPong:
+			continue
+		}
+		msg = append(msg, payload...)
+		if fin {
+			return msg, nil
+		}
+	}
+}
+
+// writeFrame writes a single masked frame, as required from clients.
+func (c *devtoolsConn) writeFrame(op byte, payload []byte) error {
+	frame := []byte{0x80 | op}
+	switch n := len(payload); {
+	case n < 126:
+		frame = append(frame, 0x80|byte(n))
+	case n <= 0xffff:
+		frame = append(frame, 0x80|126, byte(n>>8), byte(n))
+	default:
+		var ext [8]byte
+		binary.BigEndian.PutUint64(ext[:], uint64(n))
+		frame = append(append(frame, 0x80|127), ext[:]...)
+	}
+	var mask [4]byte
+	if _, err := rand.Read(mask[:]); err != nil {
+		return err
+	}
+	frame = append(frame, mask[:]...)
+	for i, b := range payload {
+		frame = append(frame, b^mask[i%4])
+	}
+
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	_, err := c.conn.Write(frame)
+	return err
+}
+
+// devtools connects to the DevTools endpoint of the current window. The
+// browser's debugger address must be reachable from this process, which is
+// the case for a local chromedriver but usually not for a remote Grid node.
+func (wd *remoteWebDriver) devtools() (*devtoolsConn, error) {
+	if !wd.isChromium() {
+		return nil, ErrNotSupported
+	}
+	opts, _ := wd.sessionCaps["goog:chromeOptions"].(map[string]interface{})
+	addr, _ := opts["debuggerAddress"].(string)
+	if addr == "" {
+		return nil, errors.New("devtools: session has no debugger address")
+	}
+	handle, err := wd.CurrentWindowHandle()
+	if err != nil {
+		return nil, err
+	}
+	// chromedriver window handles are DevTools target ids.
+	target := strings.TrimPrefix(handle, "CDwindow-")
+	return dialDevtools("ws://" + addr + "/devtools/page/" + target)
+}
@@ -1,2 +1,9 @@
 // A Selenium WebDriver client for browser testing of Web applications.
+//
+// WebDriver methods take no context.Context, so they can be called directly
+// from scripts. To bound or cancel commands, set a context on the driver:
+// SetContext ends the session when its context is done, SetCommandContext
+// only aborts the commands running under it. Simple returns a driver for the
+// same session which ignores those contexts, running every command under
+// context.Background().
 package selenium
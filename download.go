@@ -0,0 +1,42 @@
+package selenium
+
+import (
+	"fmt"
+	"os"
+	"time"
+)
+
+// WaitForStableFile waits until the file at path exists and its size has not
+// changed for stableFor, or returns an error once timeout elapses. While the
+// browser's partial download (path+".crdownload" or path+".part") exists the
+// file is never considered stable.
+func WaitForStableFile(path string, stableFor, timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	size := int64(-1)
+	var since time.Time
+	for {
+		now := time.Now()
+		if fi, err := os.Stat(path); err == nil && !isPartialDownload(path) {
+			if fi.Size() != size {
+				size, since = fi.Size(), now
+			} else if now.Sub(since) >= stableFor {
+				return nil
+			}
+		} else {
+			size = -1
+		}
+		if now.After(deadline) {
+			return fmt.Errorf("%s did not become stable within %s", path, timeout)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
+
+func isPartialDownload(path string) bool {
+	for _, ext := range []string{".crdownload", ".part"} {
+		if _, err := os.Stat(path + ext); err == nil {
+			return true
+		}
+	}
+	return false
+}
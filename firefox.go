@@ -49,3 +49,73 @@ var defaultProfile = map[string]string{
 type FirefoxProfile struct {
 	Root string
 }
+
+// FirefoxOptions are the geckodriver specific capabilities, see
+// https://developer.mozilla.org/docs/Web/WebDriver/Capabilities/firefoxOptions.
+// Attach them with Capabilities.SetFirefoxOptions.
+type FirefoxOptions struct {
+	Args   []string               `json:"args,omitempty"`
+	Binary string                 `json:"binary,omitempty"`
+	Prefs  map[string]interface{} `json:"prefs,omitempty"`
+	// Extra are the options without a field, e.g. "profile" or "log".
+	Extra map[string]interface{} `json:"-"`
+}
+
+func (o *FirefoxOptions) MarshalJSON() ([]byte, error) {
+	type options FirefoxOptions
+	return marshalOptions((*options)(o), o.Extra)
+}
+
+func (o *FirefoxOptions) UnmarshalJSON(data []byte) error {
+	type options FirefoxOptions
+	extra, err := unmarshalOptions(data, (*options)(o))
+	o.Extra = extra
+	return err
+}
+
+// AddArgument adds a command-line argument, e.g. "-headless".
+func (o *FirefoxOptions) AddArgument(arg string) *FirefoxOptions {
+	o.Args = append(o.Args, arg)
+	return o
+}
+
+// SetBinary sets the path of the Firefox binary.
+func (o *FirefoxOptions) SetBinary(path string) *FirefoxOptions {
+	o.Binary = path
+	return o
+}
+
+// SetPrefs sets about:config preferences.
+func (o *FirefoxOptions) SetPrefs(prefs map[string]interface{}) *FirefoxOptions {
+	o.Prefs = prefs
+	return o
+}
+
+// SetFirefoxOptions sets the Firefox specific capabilities.
+func (c Capabilities) SetFirefoxOptions(o *FirefoxOptions) Capabilities {
+	c["moz:firefoxOptions"] = o
+	return c
+}
+
+// FirefoxOptions returns the options set with SetFirefoxOptions, setting
+// empty ones first if there are none, so that they can be changed in place.
+// Options set otherwise, e.g. as a map decoded from JSON, are converted.
+func (c Capabilities) FirefoxOptions() *FirefoxOptions {
+	o, ok := c["moz:firefoxOptions"].(*FirefoxOptions)
+	if !ok {
+		o = new(FirefoxOptions)
+		if v, set := c["moz:firefoxOptions"]; set {
+			convertOptions(v, o)
+		}
+		c.SetFirefoxOptions(o)
+	}
+	return o
+}
+
+// HeadlessFirefox returns capabilities for Firefox without a display, e.g.
+// on CI. Add to them with FirefoxOptions.
+func HeadlessFirefox() Capabilities {
+	caps := NewCapabilities().Browser("firefox")
+	caps.FirefoxOptions().AddArgument("-headless")
+	return caps
+}
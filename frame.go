@@ -0,0 +1,152 @@
+package selenium
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// frameID converts a frame given as name or id, index or element into the id
+// parameter of the switch frame command. W3C servers don't switch by name or
+// id, so there the frame element is looked up first.
+func (wd *remoteWebDriver) frameID(frame interface{}) (interface{}, error) {
+	switch f := frame.(type) {
+	case string:
+		if !wd.w3c {
+			return f, nil
+		}
+		elem, err := wd.FindElement(ByCSSSelector, frameSelector(f))
+		if err != nil {
+			return nil, err
+		}
+		return elementRef(elem.(*remoteWE)), nil
+	case nil, int:
+		return f, nil
+	case *remoteWE:
+		return elementRef(f), nil
+	}
+	return nil, fmt.Errorf("invalid frame %v (%T)", frame, frame)
+}
+
+// frameSelector is a CSS selector for the frames with name or id.
+func frameSelector(name string) string {
+	quoted := `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\a `).Replace(name) + `"`
+	var selectors []string
+	for _, attr := range []string{"id", "name"} {
+		for _, tag := range []string{"frame", "iframe"} {
+			selectors = append(selectors, tag+"["+attr+"="+quoted+"]")
+		}
+	}
+	return strings.Join(selectors, ",")
+}
+
+// frameName is how frame, valid for frameID, appears in CurrentFramePath.
+func frameName(frame interface{}) string {
+	switch f := frame.(type) {
+	case int:
+		return strconv.Itoa(f)
+	case *remoteWE:
+		return f.id
+	}
+	return fmt.Sprint(frame)
+}
+
+// switchedFrame is a frame of the path in CurrentFramePath, with the id it
+// was switched into by.
+type switchedFrame struct {
+	name string
+	id   interface{}
+}
+
+func (wd *remoteWebDriver) CurrentFramePath() []string {
+	wd.mu.Lock()
+	defer wd.mu.Unlock()
+	var path []string
+	for _, f := range wd.frames {
+		path = append(path, f.name)
+	}
+	return path
+}
+
+// resetFrames clears the frame path after a command that returned to the
+// top-level document, unless the command failed with err.
+func (wd *remoteWebDriver) resetFrames(err error) error {
+	if err == nil {
+		wd.mu.Lock()
+		wd.frames = nil
+		wd.mu.Unlock()
+	}
+	return err
+}
+
+// InFrame switches wd into frame, given as name or id, index or element, runs
+// fn and switches back to the top-level document, even if fn fails.
+func InFrame(wd WebDriver, frame interface{}, fn func() error) error {
+	if err := wd.SwitchFrame(frame); err != nil {
+		return err
+	}
+	err := fn()
+	if err2 := wd.SwitchToDefaultContent(); err == nil {
+		err = err2
+	}
+	return err
+}
+
+// DragAcrossFrames drags source, in the current document, onto target inside
+// targetFrame, a child frame given as name or id, index or element. The drop
+// point is computed from the frame's offset in the current document.
+func DragAcrossFrames(wd WebDriver, source WebElement, targetFrame interface{}, target WebElement) error {
+	if targetFrame == nil {
+		return fmt.Errorf("invalid frame %v", targetFrame)
+	}
+	offset, err := scriptPoint(wd, `var f = arguments[0];
+	if (typeof f === "number") {
+		f = window.frames[f].frameElement;
+	} else if (typeof f === "string") {
+		f = document.getElementById(f) || document.getElementsByName(f)[0];
+	}
+	var rect = f.getBoundingClientRect();
+	return [rect.left + f.clientLeft, rect.top + f.clientTop];`, targetFrame)
+	if err != nil {
+		return err
+	}
+
+	if err := wd.SwitchFrame(targetFrame); err != nil {
+		return err
+	}
+	center, err := scriptPoint(wd, `var rect = arguments[0].getBoundingClientRect();
+	return [rect.left + rect.width / 2, rect.top + rect.height / 2];`, target)
+	if err2 := wd.SwitchFrameParent(); err == nil {
+		err = err2
+	}
+	if err != nil {
+		return err
+	}
+
+	x, y := int(offset[0]+center[0]), int(offset[1]+center[1])
+	a := wd.Actions()
+	a.Pointer("mouse", MousePointer).
+		PointerMove(source, 0, 0, 0).
+		PointerDown(LeftButton).
+		PointerMove(nil, x, y, 250*time.Millisecond).
+		PointerUp(LeftButton)
+	return a.Perform()
+}
+
+// scriptPoint runs script with arg and returns the [x, y] it evaluates to.
+func scriptPoint(wd WebDriver, script string, arg interface{}) ([]float64, error) {
+	res, err := wd.ExecuteScript(script, []interface{}{arg})
+	if err != nil {
+		return nil, err
+	}
+	values, _ := res.([]interface{})
+	if len(values) != 2 {
+		return nil, fmt.Errorf("bad point %v", res)
+	}
+	point := make([]float64, 2)
+	for i, v := range values {
+		point[i], _ = v.(float64)
+	}
+	return point, nil
+}
@@ -0,0 +1,54 @@
+package selenium
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// NodeInfo describes the Selenium Grid node running a session.
+type NodeInfo struct {
+	// NodeURL is the node's address, e.g. "http://10.0.0.5:5555".
+	NodeURL string `json:"proxyId"`
+	// InternalKey identifies the test slot on the node.
+	InternalKey string `json:"internalKey"`
+	// InactivityTime is the time since the session's last command, in
+	// milliseconds.
+	InactivityTime int64 `json:"inactivityTime"`
+}
+
+func (wd *remoteWebDriver) SessionNodeInfo() (*NodeInfo, error) {
+	base := wd.gridURL
+	if base == "" {
+		base = strings.TrimSuffix(strings.TrimSuffix(wd.executor, "/"), "/wd/hub")
+	}
+	u := base + "/grid/api/testsession?session=" + url.QueryEscape(wd.sessionID())
+	var body []byte
+	err := wd.command(func() (err error) {
+		body, err = wd.roundTrip("SessionNodeInfo", "GET", u, nil, func(res *http.Response, buf []byte) ([]byte, error) {
+			if res.StatusCode == http.StatusNotFound {
+				// Standalone servers have no Grid API.
+				return nil, ErrNotSupported
+			}
+			return buf, nil
+		})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	var reply struct {
+		NodeInfo
+		Success bool   `json:"success"`
+		Msg     string `json:"msg"`
+	}
+	if err := json.Unmarshal(body, &reply); err != nil {
+		return nil, fmt.Errorf("bad grid reply %s: %s", body, err)
+	}
+	if !reply.Success {
+		return nil, fmt.Errorf("grid: %s", reply.Msg)
+	}
+	return &reply.NodeInfo, nil
+}
@@ -0,0 +1,173 @@
+package selenium
+
+import (
+	"errors"
+	"sync"
+)
+
+// ErrPoolClosed is returned by Pool.Acquire once the pool has been closed.
+var ErrPoolClosed = errors.New("pool closed")
+
+// ErrNotAcquired is returned by Pool.Release for a session which is not
+// acquired from the pool, e.g. one released twice.
+var ErrNotAcquired = errors.New("session not acquired from the pool")
+
+// Pool hands out pre-created sessions so that tests running in parallel do
+// not each pay for starting a browser. A session is used by one caller at a
+// time between Acquire and Release.
+type Pool struct {
+	capabilities Capabilities
+	executor     string
+	opts         []DriverOption
+
+	// idle holds the released sessions, nil for a slot whose session must be
+	// created again.
+	idle chan WebDriver
+
+	mu      sync.Mutex
+	closed  bool
+	done    chan struct{}
+	drivers map[WebDriver]bool
+	// acquired holds the sessions handed out by Acquire and not yet
+	// released.
+	acquired map[WebDriver]bool
+}
+
+// NewPool starts size sessions with the given capabilities on executor.
+func NewPool(capabilities Capabilities, executor string, size int, opts ...DriverOption) (*Pool, error) {
+	if size < 1 {
+		return nil, errors.New("pool size must be positive")
+	}
+	p := &Pool{
+		capabilities: capabilities,
+		executor:     executor,
+		opts:         opts,
+		idle:         make(chan WebDriver, size),
+		done:         make(chan struct{}),
+		drivers:      make(map[WebDriver]bool),
+		acquired:     make(map[WebDriver]bool),
+	}
+	for i := 0; i < size; i++ {
+		wd, err := p.newSession()
+		if err != nil {
+			p.Close()
+			return nil, err
+		}
+		p.idle <- wd
+	}
+	return p, nil
+}
+
+func (p *Pool) newSession() (WebDriver, error) {
+	wd, err := NewRemote(p.capabilities, p.executor, p.opts...)
+	if err != nil {
+		return nil, err
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		wd.Quit()
+		return nil, ErrPoolClosed
+	}
+	p.drivers[wd] = true
+	return wd, nil
+}
+
+// discard quits a session that is no longer usable.
+func (p *Pool) discard(wd WebDriver) {
+	p.mu.Lock()
+	delete(p.drivers, wd)
+	p.mu.Unlock()
+	wd.Quit()
+}
+
+// Acquire waits for an idle session and returns it. A session whose browser
+// no longer responds is replaced by a new one.
+func (p *Pool) Acquire() (WebDriver, error) {
+	select {
+	case <-p.done:
+		return nil, ErrPoolClosed
+	default:
+	}
+
+	var wd WebDriver
+	select {
+	case wd = <-p.idle:
+	case <-p.done:
+		return nil, ErrPoolClosed
+	}
+
+	if wd != nil {
+		if _, err := wd.CurrentWindowHandle(); err == nil {
+			return p.checkOut(wd), nil
+		}
+		p.discard(wd)
+	}
+	wd, err := p.newSession()
+	if err != nil {
+		// Give the slot back so that a later Acquire can try again.
+		p.idle <- nil
+		return nil, err
+	}
+	return p.checkOut(wd), nil
+}
+
+// checkOut records that wd was handed out by Acquire.
+func (p *Pool) checkOut(wd WebDriver) WebDriver {
+	p.mu.Lock()
+	p.acquired[wd] = true
+	p.mu.Unlock()
+	return wd
+}
+
+// Release resets the session's state, clearing the cookies and navigating to
+// about:blank, and returns it to the pool. The session must not be used
+// afterwards. A session that can't be reset is replaced by a new one. Release
+// returns ErrNotAcquired, leaving the pool unchanged, for a session which
+// isn't acquired from the pool, e.g. one already released.
+func (p *Pool) Release(wd WebDriver) error {
+	p.mu.Lock()
+	if !p.acquired[wd] {
+		p.mu.Unlock()
+		return ErrNotAcquired
+	}
+	delete(p.acquired, wd)
+	closed := p.closed
+	p.mu.Unlock()
+	if closed {
+		p.discard(wd)
+		return nil
+	}
+
+	if err := wd.DeleteAllCookies(); err != nil {
+		p.discard(wd)
+		wd = nil
+	} else if err := wd.Get("about:blank"); err != nil {
+		p.discard(wd)
+		wd = nil
+	}
+	p.idle <- wd
+	return nil
+}
+
+// Close quits all sessions of the pool, including the ones still acquired.
+func (p *Pool) Close() error {
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		return nil
+	}
+	p.closed = true
+	close(p.done)
+	drivers := p.drivers
+	p.drivers = make(map[WebDriver]bool)
+	p.mu.Unlock()
+
+	var err error
+	for wd := range drivers {
+		if e := wd.Quit(); e != nil && err == nil {
+			err = e
+		}
+	}
+	return err
+}
@@ -6,6 +6,7 @@ See http://code.google.com/p/selenium/wiki/JsonWireProtocol for wire protocol.
 package selenium
 
 import (
+	"archive/zip"
 	"bytes"
 	"context"
 	"encoding/base64"
@@ -18,15 +19,24 @@ import (
 	"net"
 	"net/http"
 	"net/http/httputil"
+	"net/url"
 	"os"
+	"path/filepath"
 	"strings"
 	"sync"
 	"time"
 )
 
+// Log is the default logger of all drivers, set it to nil to disable logging.
 var Log = log.New(os.Stderr, "[selenium] ", log.Ltime|log.Lmicroseconds)
 var Trace bool
 
+// Logger receives a driver's log of requests and responses, see WithLogger.
+// *log.Logger implements it.
+type Logger interface {
+	Printf(format string, args ...interface{})
+}
+
 /* Errors returned by Selenium server. */
 var errorCodes = map[int]string{
 	7:  "no such element",
@@ -57,18 +67,145 @@ const (
 )
 
 type remoteWebDriver struct {
-	id, executor string
+	executor     string
 	capabilities Capabilities
 	// FIXME
 	// profile             BrowserProfile
-	ctx context.Context
 
-	haveQuitMu sync.Mutex
-	haveQuit   bool
+	// mu guards the session id and the contexts, which change while other
+	// goroutines may be sending commands. The negotiated w3c and sessionCaps
+	// only change with the session, see the WebDriver concurrency notes.
+	mu  sync.Mutex
+	id  string
+	ctx context.Context
+	// cmdCtx, if set, scopes commands without ending the session.
+	cmdCtx context.Context
+	// w3c is set when the server negotiated the W3C WebDriver protocol
+	// instead of the JSON Wire protocol.
+	w3c bool
+	// sessionCaps are the capabilities the server returned for the session.
+	sessionCaps Capabilities
+	// frames are the frames switched into, see CurrentFramePath. It is
+	// guarded by mu.
+	frames []switchedFrame
+
+	retries        int
+	retryBackoff   time.Duration
+	commandTimeout time.Duration
+	logger         Logger
+	metricsHook    func(method, url string, status int, duration time.Duration, err error)
+	tracer         Tracer
+	httpClient     *http.Client
+	gridURL        string
+	// keepSessionOnCancel stops the end of ctx from quitting the session.
+	keepSessionOnCancel bool
+	// implicitWait is how long finds poll on the client, see
+	// ClientImplicitWait.
+	implicitWait time.Duration
+
+	// quitMu serializes Quit, which is a no-op for the session quitID that
+	// was already quit.
+	quitMu sync.Mutex
+	quitID string
+
+	// tabOf is set for drivers returned by NewTab, which share the session
+	// of tabOf.
+	tabOf *remoteWebDriver
+	// ownTab is the window the driver's commands run in once the session has
+	// tabs. It is guarded by mu.
+	ownTab string
+	// On the session driver, tabbed is set by the first NewTab and window is
+	// the window the browser is in, "" if unknown. Both are guarded by mu.
+	tabbed bool
+	window string
+	// tabMu serializes the commands of a session with tabs, which must not
+	// switch windows while another driver's command is running.
+	tabMu sync.Mutex
 }
 
 func (wd *remoteWebDriver) SetContext(ctx context.Context) {
+	wd.mu.Lock()
 	wd.ctx = ctx
+	wd.mu.Unlock()
+}
+
+func (wd *remoteWebDriver) SetCommandContext(ctx context.Context) {
+	wd.mu.Lock()
+	wd.cmdCtx = ctx
+	wd.mu.Unlock()
+}
+
+func (wd *remoteWebDriver) Simple() WebDriver {
+	id := wd.sessionID()
+	wd.mu.Lock()
+	frames := append([]switchedFrame(nil), wd.frames...)
+	wd.mu.Unlock()
+	return &remoteWebDriver{
+		id:                  id,
+		executor:            wd.executor,
+		capabilities:        wd.capabilities,
+		ctx:                 context.Background(),
+		w3c:                 wd.w3c,
+		sessionCaps:         wd.sessionCaps,
+		frames:              frames,
+		retries:             wd.retries,
+		retryBackoff:        wd.retryBackoff,
+		commandTimeout:      wd.commandTimeout,
+		logger:              wd.logger,
+		metricsHook:         wd.metricsHook,
+		tracer:              wd.tracer,
+		httpClient:          wd.httpClient,
+		gridURL:             wd.gridURL,
+		keepSessionOnCancel: wd.keepSessionOnCancel,
+		implicitWait:        wd.implicitWait,
+	}
+}
+
+func (wd *remoteWebDriver) sessionID() string {
+	if wd.tabOf != nil {
+		return wd.tabOf.sessionID()
+	}
+	wd.mu.Lock()
+	defer wd.mu.Unlock()
+	return wd.id
+}
+
+func (wd *remoteWebDriver) contexts() (ctx, cmdCtx context.Context) {
+	wd.mu.Lock()
+	defer wd.mu.Unlock()
+	return wd.ctx, wd.cmdCtx
+}
+
+// sessionCanceled reports whether the driver's context is done, resetting it
+// so that the session can be quit unless the session is kept.
+func (wd *remoteWebDriver) sessionCanceled() bool {
+	wd.mu.Lock()
+	defer wd.mu.Unlock()
+	select {
+	case <-wd.ctx.Done():
+		if !wd.keepSessionOnCancel {
+			wd.ctx = context.Background()
+		}
+		return true
+	default:
+		return false
+	}
+}
+
+// logf logs to the driver's logger, or to Log if it has none.
+func (wd *remoteWebDriver) logf(format string, args ...interface{}) {
+	if wd.logger != nil {
+		wd.logger.Printf(format, args...)
+	} else if Log != nil {
+		Log.Printf(format, args...)
+	}
+}
+
+func (wd *remoteWebDriver) client() *http.Client {
+	if wd.httpClient != nil {
+		return wd.httpClient
+	}
+	return &httpClient
 }
 
 func (wd *remoteWebDriver) url(template string, args ...interface{}) string {
@@ -76,9 +213,9 @@ func (wd *remoteWebDriver) url(template string, args ...interface{}) string {
 	return wd.executor + path
 }
 
-func (wd *remoteWebDriver) send(method, url string, data []byte) (r *reply, err error) {
+func (wd *remoteWebDriver) send(name, method, url string, data []byte) (r *reply, err error) {
 	var buf []byte
-	if buf, err = wd.execute(method, url, data); err == nil {
+	if buf, err = wd.execute(name, method, url, data); err == nil {
 		if len(buf) > 0 {
 			err = json.Unmarshal(buf, &r)
 		}
@@ -88,61 +225,189 @@ func (wd *remoteWebDriver) send(method, url string, data []byte) (r *reply, err
 
 // VoidExecute ...
 func (wd *remoteWebDriver) VoidExecute(url string, params interface{}) error {
-	return wd.voidCommand(url, params)
+	return wd.voidCommand("VoidExecute", url, params)
+}
+
+func (wd *remoteWebDriver) Execute(method, path string, params interface{}) (json.RawMessage, error) {
+	var data []byte
+	if params != nil {
+		var err error
+		if data, err = json.Marshal(params); err != nil {
+			return nil, err
+		}
+	}
+	// Not formatted, so that the path can be percent-encoded.
+	url := wd.executor + strings.Replace(path, "%s", wd.sessionID(), 1)
+	r, err := wd.send("Execute", method, url, data)
+	if err != nil || r == nil {
+		return nil, err
+	}
+	return r.Value, nil
 }
 
 // ErrCanceled is returned when the context is cancelled.
 var ErrCanceled = errors.New("cancelled")
 
-func (wd *remoteWebDriver) execute(method, url string, data []byte) (buf []byte, err error) {
-	select {
-	case <-wd.ctx.Done():
-		err = ErrCanceled
-		wd.ctx = context.Background()
-		_ = wd.Quit()
-		return
-	default:
+func (wd *remoteWebDriver) execute(name, method, url string, data []byte) (buf []byte, err error) {
+	err = wd.command(func() (err error) {
+		buf, err = wd.executeInWindow(name, method, url, data)
+		return err
+	})
+	return buf, err
+}
+
+// command runs fn, which sends its requests with executeInWindow, in the
+// window and frames of wd. Once the session has tabs, the commands of its
+// drivers run one at a time.
+func (wd *remoteWebDriver) command(fn func() error) (err error) {
+	if wd.sessionCanceled() {
+		if !wd.keepSessionOnCancel {
+			_ = wd.Quit()
+		}
+		return ErrCanceled
 	}
 	defer func() {
-		select {
-		case <-wd.ctx.Done():
+		if wd.sessionCanceled() {
 			err = ErrCanceled
-			wd.ctx = context.Background()
-			_ = wd.Quit()
-			return
-		default:
+			if !wd.keepSessionOnCancel {
+				_ = wd.Quit()
+			}
 		}
 	}()
 
-	if Log != nil {
-		Log.Printf("-> %s %s [%d bytes]", method, url, len(data))
+	root := wd.root()
+	root.mu.Lock()
+	tabbed := root.tabbed
+	root.mu.Unlock()
+	if tabbed {
+		root.tabMu.Lock()
+		defer root.tabMu.Unlock()
+		if err := wd.enterWindow(); err != nil {
+			return err
+		}
 	}
-	req, err := http.NewRequest(method, url, bytes.NewBuffer(data))
-	if err != nil {
-		return nil, err
+	return fn()
+}
+
+// root returns the session driver of wd.
+func (wd *remoteWebDriver) root() *remoteWebDriver {
+	if wd.tabOf != nil {
+		return wd.tabOf
 	}
-	req.Header.Add("Accept", jsonMIMEType)
-	if method == "POST" {
-		req.Header.Add("Content-Type", jsonMIMEType)
+	return wd
+}
+
+// enterWindow switches the browser back to the window and frames of wd if
+// another driver of the session switched away since wd's last command.
+func (wd *remoteWebDriver) enterWindow() error {
+	root := wd.root()
+	wd.mu.Lock()
+	tab, frames := wd.ownTab, wd.frames
+	wd.mu.Unlock()
+	root.mu.Lock()
+	current := root.window
+	root.mu.Unlock()
+	if current == tab {
+		return nil
 	}
 
-	if Trace {
-		if dump, err := httputil.DumpRequest(req, true); err == nil && Log != nil {
-			Log.Printf("-> TRACE\n%s", dump)
+	if err := wd.inWindowCommand("SwitchWindow", "/session/%s/window", wd.windowParams(tab)); err != nil {
+		return err
+	}
+	root.mu.Lock()
+	root.window = tab
+	root.mu.Unlock()
+	// Switching windows returned to the top-level document.
+	for i, f := range frames {
+		if err := wd.inWindowCommand("SwitchFrame", "/session/%s/frame", map[string]interface{}{"id": f.id}); err != nil {
+			wd.mu.Lock()
+			wd.frames = frames[:i]
+			wd.mu.Unlock()
+			return err
+		}
+	}
+	return nil
+}
+
+// inWindowCommand is voidCommand for use inside command.
+func (wd *remoteWebDriver) inWindowCommand(name, urlTemplate string, params interface{}) error {
+	var data []byte
+	if params != nil {
+		var err error
+		if data, err = json.Marshal(params); err != nil {
+			return err
 		}
 	}
+	_, err := wd.executeInWindow(name, "POST", wd.url(urlTemplate, wd.sessionID()), data)
+	return err
+}
+
+// executeInWindow sends a request, in whichever window the browser is in.
+func (wd *remoteWebDriver) executeInWindow(name, method, url string, data []byte) ([]byte, error) {
+	return wd.roundTrip(name, method, url, data, readReply)
+}
+
+// roundTrip sends a request under the driver's contexts and command timeout,
+// tracing and measuring it, and returns what handle makes of the response
+// and its body.
+func (wd *remoteWebDriver) roundTrip(name, method, url string, data []byte, handle func(res *http.Response, buf []byte) ([]byte, error)) (buf []byte, err error) {
+	ctx, cmdCtx := wd.contexts()
+	if cmdCtx != nil {
+		select {
+		case <-cmdCtx.Done():
+			return nil, ErrCanceled
+		default:
+		}
+		merged, cancel := context.WithCancel(ctx)
+		defer cancel()
+		go func() {
+			select {
+			case <-cmdCtx.Done():
+				cancel()
+			case <-merged.Done():
+			}
+		}()
+		ctx = merged
+		defer func() {
+			// Only this command is aborted, the session stays alive.
+			if err != nil && cmdCtx.Err() != nil {
+				err = ErrCanceled
+			}
+		}()
+	}
+
+	if wd.commandTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, wd.commandTimeout)
+		defer cancel()
+	}
+
+	if wd.tracer != nil {
+		var end func(error)
+		ctx, end = wd.tracer.StartSpan(ctx, name)
+		defer func() {
+			end(err)
+		}()
+	}
 
-	req = req.WithContext(wd.ctx)
+	var status int
+	if hook := wd.metricsHook; hook != nil {
+		start := time.Now()
+		defer func() {
+			hook(method, url, status, time.Since(start), err)
+		}()
+	}
 
-	res, err := httpClient.Do(req)
+	res, err := wd.do(ctx, method, url, data)
 	if err != nil {
 		return nil, err
 	}
 	defer res.Body.Close()
+	status = res.StatusCode
 
 	if Trace {
-		if dump, err := httputil.DumpResponse(res, true); err == nil && Log != nil {
-			Log.Printf("<- TRACE\n%s", dump)
+		if dump, err := httputil.DumpResponse(res, true); err == nil {
+			wd.logf("<- TRACE\n%s", dump)
 		}
 	}
 
@@ -150,31 +415,18 @@ func (wd *remoteWebDriver) execute(method, url string, data []byte) (buf []byte,
 	if err != nil {
 		return nil, err
 	}
-	if Log != nil {
-		Log.Printf("<- %s (%s) [%d bytes]", res.Status, res.Header["Content-Type"], len(buf))
-	}
+	wd.logf("<- %s (%s) [%d bytes]", res.Status, res.Header["Content-Type"], len(buf))
+	return handle(res, buf)
+}
 
+// readReply returns the body of a successful WebDriver reply, or its error.
+func readReply(res *http.Response, buf []byte) ([]byte, error) {
 	pE := func(r *reply) error {
-		sr := &replyValue{}
-		var backendError string
-		err = json.Unmarshal([]byte(r.Value), sr)
-		if err == nil {
-			// can analyze the error
-			if sr.Message != "" {
-				rm := &replyMessage{}
-				err = json.Unmarshal([]byte(sr.Message), rm)
-				if err == nil {
-					backendError = rm.ErrorMessage
-				}
-			}
+		e := replyError(r)
+		if e.Code == 26 {
+			return unexpectedAlertError(e, r)
 		}
-
-		message, ok := errorCodes[r.Status]
-		if !ok {
-			message = fmt.Sprintf("unknown error - %d", r.Status)
-		}
-
-		return fmt.Errorf("%v%v", message, " - "+fmt.Sprintf("%q", backendError))
+		return e
 	}
 
 	if res.StatusCode >= 400 {
@@ -210,6 +462,44 @@ func (wd *remoteWebDriver) execute(method, url string, data []byte) (buf []byte,
 	return buf, nil
 }
 
+// do sends a request. Idempotent commands and session creation are retried
+// on network errors if the driver was created with RetryOnNetworkError.
+func (wd *remoteWebDriver) do(ctx context.Context, method, url string, data []byte) (*http.Response, error) {
+	retryable := method == "GET" || (method == "POST" && url == wd.url("/session"))
+	for attempt := 0; ; attempt++ {
+		wd.logf("-> %s %s [%d bytes]", method, url, len(data))
+		req, err := http.NewRequest(method, url, bytes.NewBuffer(data))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Add("Accept", jsonMIMEType)
+		if method == "POST" {
+			req.Header.Add("Content-Type", jsonMIMEType)
+		}
+
+		if Trace {
+			if dump, err := httputil.DumpRequest(req, true); err == nil {
+				wd.logf("-> TRACE\n%s", dump)
+			}
+		}
+
+		req = req.WithContext(ctx)
+
+		res, err := wd.client().Do(req)
+		if err == nil || !retryable || attempt >= wd.retries || ctx.Err() != nil {
+			return res, err
+		}
+
+		backoff := wd.retryBackoff << uint(attempt)
+		wd.logf("-> retrying in %s: %s", backoff, err)
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(backoff):
+		}
+	}
+}
+
 var httpClient = http.Client{
 	// WebDriver requires that all requests have an 'Accept: application/json' header. We must add
 	// it here because by default net/http will not include that header when following redirects.
@@ -230,8 +520,15 @@ var httpClient = http.Client{
 			Timeout: 30 * time.Second,
 		}).Dial,
 		TLSHandshakeTimeout: 30 * time.Second,
+		// Keep enough connections to a Selenium server for concurrent
+		// sessions, the Go default of 2 per host makes them churn.
+		MaxIdleConns:        100,
+		MaxIdleConnsPerHost: 10,
+		IdleConnTimeout:     90 * time.Second,
 	},
-	Timeout: 60 * time.Second,
+	// Commands are bounded by WithCommandTimeout, this only catches
+	// connections that hang indefinitely.
+	Timeout: 30 * time.Minute,
 }
 
 // Server reply to WebDriver command.
@@ -243,12 +540,182 @@ type reply struct {
 
 type replyValue struct {
 	Message string `json:"message"`
+	// Error is the error code of W3C error replies.
+	Error string `json:"error"`
 }
 
 type replyMessage struct {
 	ErrorMessage string `json:"errorMessage"`
 }
 
+// Error is a failed command reported by the WebDriver server.
+type Error struct {
+	// Code is the JSON Wire status code, e.g. 7 for "no such element". W3C
+	// errors are mapped to the equivalent code, or 0 if there is none.
+	Code int
+	// Message describes the error code.
+	Message string
+	// Details is the server's error message.
+	Details string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s - %q", e.Message, e.Details)
+}
+
+// ErrStaleElement matches, with errors.Is, the error of a command on an
+// element which is no longer attached to the page.
+var ErrStaleElement = errors.New("stale element reference")
+
+// ErrNoSuchElement matches, with errors.Is, the error of a find that matched
+// no element.
+var ErrNoSuchElement = errors.New("no such element")
+
+// Is reports whether e is classified as target, see ErrStaleElement and
+// ErrNoSuchElement.
+func (e *Error) Is(target error) bool {
+	switch target {
+	case ErrStaleElement:
+		return e.Code == 10
+	case ErrNoSuchElement:
+		return e.Code == 7
+	}
+	return false
+}
+
+/* JSON Wire status codes of W3C error codes. */
+var w3cErrorCodes = map[string]int{
+	"invalid session id":        6,
+	"no such element":           7,
+	"no such frame":             8,
+	"unknown command":           9,
+	"unknown method":            9,
+	"stale element reference":   10,
+	"element not interactable":  11,
+	"invalid element state":     12,
+	"unknown error":             13,
+	"javascript error":          17,
+	"timeout":                   21,
+	"no such window":            23,
+	"invalid cookie domain":     24,
+	"unable to set cookie":      25,
+	"unexpected alert open":     26,
+	"no such alert":             27,
+	"script timeout":            28,
+	"invalid selector":          32,
+	"session not created":       33,
+	"move target out of bounds": 34,
+	"no such cookie":            62,
+}
+
+// ErrUnexpectedAlertOpen is the error of a command that an open alert
+// blocked. Read its Text, then accept or dismiss the alert to continue.
+type ErrUnexpectedAlertOpen struct {
+	Err *Error
+	// Text is the alert's text, empty if the server didn't report it.
+	Text string
+}
+
+func (e *ErrUnexpectedAlertOpen) Error() string {
+	return fmt.Sprintf("%s (alert text %q)", e.Err, e.Text)
+}
+
+func (e *ErrUnexpectedAlertOpen) Unwrap() error {
+	return e.Err
+}
+
+// unexpectedAlertError reads the alert text from the data of W3C errors or
+// the alert of JSON Wire ones.
+func unexpectedAlertError(e *Error, r *reply) *ErrUnexpectedAlertOpen {
+	var v struct {
+		Data struct {
+			Text string `json:"text"`
+		} `json:"data"`
+		Alert struct {
+			Text string `json:"text"`
+		} `json:"alert"`
+	}
+	json.Unmarshal(r.Value, &v)
+	text := v.Data.Text
+	if text == "" {
+		text = v.Alert.Text
+	}
+	return &ErrUnexpectedAlertOpen{Err: e, Text: text}
+}
+
+func replyError(r *reply) *Error {
+	e := &Error{Code: r.Status}
+	sr := &replyValue{}
+	if err := json.Unmarshal(r.Value, sr); err == nil {
+		if sr.Error != "" {
+			e.Code = w3cErrorCodes[sr.Error]
+			e.Message = sr.Error
+			e.Details = sr.Message
+			return e
+		}
+		// can analyze the error
+		if sr.Message != "" {
+			rm := &replyMessage{}
+			if err := json.Unmarshal([]byte(sr.Message), rm); err == nil {
+				e.Details = rm.ErrorMessage
+			} else {
+				e.Details = sr.Message
+			}
+		}
+	}
+
+	message, ok := errorCodes[r.Status]
+	if !ok {
+		message = fmt.Sprintf("unknown error - %d", r.Status)
+	}
+	e.Message = message
+	return e
+}
+
+// ErrorCode returns the JSON Wire status code of err, an *Error reported by
+// the server, e.g. 7 for "no such element", and false if err is any other
+// error, such as a network failure. W3C errors are reported with the
+// equivalent JSON Wire code.
+func ErrorCode(err error) (int, bool) {
+	var e *Error
+	if !errors.As(err, &e) {
+		return 0, false
+	}
+	return e.Code, true
+}
+
+// isUnknownCommand reports whether err means the server doesn't implement
+// the command.
+func isUnknownCommand(err error) bool {
+	code, ok := ErrorCode(err)
+	return ok && code == 9
+}
+
+func isNoSuchElement(err error) bool {
+	code, ok := ErrorCode(err)
+	return ok && code == 7
+}
+
+// RetryOnStale calls fn until it returns an error other than
+// ErrStaleElement, at most attempts times but at least once, and returns its
+// last error. Find the elements inside fn so that a retry uses fresh
+// references:
+//
+//	err := RetryOnStale(3, func() error {
+//		elem, err := wd.FindElement(ById, "submit")
+//		if err != nil {
+//			return err
+//		}
+//		return elem.Click()
+//	})
+func RetryOnStale(attempts int, fn func() error) error {
+	for i := 1; ; i++ {
+		if err := fn(); i >= attempts || !errors.Is(err, ErrStaleElement) {
+			return err
+		}
+	}
+}
+
 func (r *reply) readValue(v interface{}) error {
 	return json.Unmarshal(r.Value, v)
 }
@@ -259,61 +726,229 @@ type Session struct {
 	Capabilities Capabilities
 }
 
+// DriverOption configures a WebDriver created by NewRemote or AttachToSession.
+type DriverOption func(*remoteWebDriver)
+
+// RetryOnNetworkError retries GET commands and session creation up to
+// attempts times when the server can't be reached, waiting backoff, then
+// twice as long before each further attempt. WebDriver errors are never
+// retried.
+func RetryOnNetworkError(attempts int, backoff time.Duration) DriverOption {
+	return func(wd *remoteWebDriver) {
+		wd.retries = attempts
+		wd.retryBackoff = backoff
+	}
+}
+
+// defaultCommandTimeout is how long the client waits for a command unless
+// configured with WithCommandTimeout.
+const defaultCommandTimeout = 60 * time.Second
+
+// WithCommandTimeout sets how long the client waits for the reply to each
+// command (default 60 seconds). With zero only the HTTP client's timeout
+// applies, 30 minutes for the default client, see WithHTTPClient. It is
+// independent of the server-side timeouts set with SetTimeout: raise it for
+// scripts or page loads the server is allowed to run for longer.
+func WithCommandTimeout(d time.Duration) DriverOption {
+	return func(wd *remoteWebDriver) {
+		wd.commandTimeout = d
+	}
+}
+
+// WithLogger logs the driver's requests and responses to l instead of Log.
+func WithLogger(l Logger) DriverOption {
+	return func(wd *remoteWebDriver) {
+		wd.logger = l
+	}
+}
+
+// WithMetricsHook calls hook after every command with the HTTP status of the
+// reply (zero if there was none), how long the command took and its error.
+func WithMetricsHook(hook func(method, url string, status int, duration time.Duration, err error)) DriverOption {
+	return func(wd *remoteWebDriver) {
+		wd.metricsHook = hook
+	}
+}
+
+// WithHTTPClient sends the driver's requests with c instead of the shared
+// default client, e.g. to tune the connection pool of its transport. The
+// default client's CheckRedirect adds the "Accept: application/json" header,
+// which WebDriver requires, to redirected requests, e.g. from a Grid hub to a
+// node; c must do the same.
+func WithHTTPClient(c *http.Client) DriverOption {
+	return func(wd *remoteWebDriver) {
+		wd.httpClient = c
+	}
+}
+
+// KeepSessionOnCancel sets whether the session survives the end of the
+// context set with SetContext. By default the session is quit; when kept,
+// commands fail with ErrCanceled until SetContext is called with a new
+// context, and Quit still ends the session.
+func KeepSessionOnCancel(keep bool) DriverOption {
+	return func(wd *remoteWebDriver) {
+		wd.keepSessionOnCancel = keep
+	}
+}
+
+// ClientImplicitWait makes the finds of the driver, its elements and
+// shadow roots poll for up to d, until FindElement finds the element or
+// FindElements finds at least one, instead of failing at once. Unlike
+// SetImplicitWaitTimeout the waiting happens in the client, so it works
+// with servers that ignore the implicit wait. It is off by default.
+func ClientImplicitWait(d time.Duration) DriverOption {
+	return func(wd *remoteWebDriver) {
+		wd.implicitWait = d
+	}
+}
+
+// WithGridURL sets the base URL of the Selenium Grid hub, e.g.
+// "http://hub:4444", for SessionNodeInfo. By default it is the executor
+// without its "/wd/hub" path.
+func WithGridURL(base string) DriverOption {
+	return func(wd *remoteWebDriver) {
+		wd.gridURL = base
+	}
+}
+
+// AttachCapabilities gives AttachToSession the capabilities the session was
+// created with. W3C servers can't report them for an existing session, so
+// without this option an attached W3C driver doesn't know its browser:
+// SupportsCDP and the other predicates are false, SessionCapabilities fails
+// and Restart starts a session without capabilities.
+func AttachCapabilities(caps Capabilities) DriverOption {
+	return func(wd *remoteWebDriver) {
+		wd.capabilities, wd.sessionCaps = caps, caps
+	}
+}
+
+// Tracer starts a span for every command, named after the WebDriver,
+// WebElement, ShadowRoot or Actions method that sent it, e.g. "FindElement",
+// also when the method is called by another one such as WaitForElement or
+// Wait.Until. The context it returns is used for the
+// HTTP request, end is called with the command's error.
+type Tracer interface {
+	StartSpan(ctx context.Context, name string) (spanCtx context.Context, end func(error))
+}
+
+// WithTracer traces the driver's commands with t.
+func WithTracer(t Tracer) DriverOption {
+	return func(wd *remoteWebDriver) {
+		wd.tracer = t
+	}
+}
+
 /* Create new remote client, this will also start a new session.
    capabilities - the desired capabilities, see http://goo.gl/SNlAk
    executor - the URL to the Selenim server
+   opts - options configuring the client
 */
-func NewRemote(capabilities Capabilities, executor string) (WebDriver, error) {
+func NewRemote(capabilities Capabilities, executor string, opts ...DriverOption) (WebDriver, error) {
+	wd, _, err := NewRemoteSession(capabilities, executor, opts...)
+	return wd, err
+}
+
+// NewRemoteSession is like NewRemote, but also returns the new session's id
+// and the capabilities the server returned for it.
+func NewRemoteSession(capabilities Capabilities, executor string, opts ...DriverOption) (WebDriver, *Session, error) {
 	if executor == "" {
 		executor = defaultExecutor
 	}
 
 	wd := &remoteWebDriver{
-		executor:     executor,
-		capabilities: capabilities,
-		ctx:          context.Background(),
+		executor:       executor,
+		capabilities:   capabilities,
+		ctx:            context.Background(),
+		commandTimeout: defaultCommandTimeout,
+	}
+	for _, opt := range opts {
+		opt(wd)
 	}
 	// FIXME: Handle profile
 
-	_, err := wd.NewSession()
+	id, err := wd.NewSession()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return wd, &Session{Id: id, Capabilities: wd.sessionCaps}, nil
+}
+
+/* Create a remote client for an existing session, without starting a new one.
+   The session is validated by querying its current URL, whose reply tells
+   whether the server speaks the JSON Wire or the W3C protocol. The session's
+   capabilities are read from JSON Wire servers; pass them with
+   AttachCapabilities for W3C servers, which can't report them.
+*/
+func AttachToSession(executor, sessionID string, opts ...DriverOption) (WebDriver, error) {
+	if executor == "" {
+		executor = defaultExecutor
+	}
+
+	wd := &remoteWebDriver{
+		id:             sessionID,
+		executor:       executor,
+		ctx:            context.Background(),
+		commandTimeout: defaultCommandTimeout,
+	}
+	for _, opt := range opts {
+		opt(wd)
+	}
+
+	r, err := wd.send("AttachToSession", "GET", wd.url("/session/%s/url", wd.sessionID()), nil)
 	if err != nil {
 		return nil, err
 	}
+	if r == nil {
+		return nil, errors.New("bad current URL reply for the session")
+	}
+	// Only JSON Wire replies carry the session id.
+	wd.w3c = r.SessionId == ""
+	if !wd.w3c && wd.sessionCaps == nil {
+		caps, err := wd.Capabilities()
+		if err != nil {
+			return nil, err
+		}
+		wd.sessionCaps = caps
+		if wd.capabilities == nil {
+			wd.capabilities = caps
+		}
+	}
 
 	return wd, nil
 }
 
-func (wd *remoteWebDriver) stringCommand(urlTemplate string) (v string, err error) {
+func (wd *remoteWebDriver) stringCommand(name, urlTemplate string) (v string, err error) {
 	var r *reply
-	if r, err = wd.send("GET", wd.url(urlTemplate, wd.id), nil); err == nil {
+	if r, err = wd.send(name, "GET", wd.url(urlTemplate, wd.sessionID()), nil); err == nil {
 		err = r.readValue(&v)
 	}
 	return
 }
 
-func (wd *remoteWebDriver) voidCommand(urlTemplate string, params interface{}) (err error) {
+func (wd *remoteWebDriver) voidCommand(name, urlTemplate string, params interface{}) (err error) {
 	var data []byte
 	if params != nil {
 		data, err = json.Marshal(params)
 	}
 	if err == nil {
-		_, err = wd.send("POST", wd.url(urlTemplate, wd.id), data)
+		_, err = wd.send(name, "POST", wd.url(urlTemplate, wd.sessionID()), data)
 	}
 	return
 
 }
 
-func (wd remoteWebDriver) stringsCommand(urlTemplate string) (v []string, err error) {
+func (wd remoteWebDriver) stringsCommand(name, urlTemplate string) (v []string, err error) {
 	var r *reply
-	if r, err = wd.send("GET", wd.url(urlTemplate, wd.id), nil); err == nil {
+	if r, err = wd.send(name, "GET", wd.url(urlTemplate, wd.sessionID()), nil); err == nil {
 		err = r.readValue(&v)
 	}
 	return
 }
 
-func (wd *remoteWebDriver) boolCommand(urlTemplate string) (v bool, err error) {
+func (wd *remoteWebDriver) boolCommand(name, urlTemplate string) (v bool, err error) {
 	var r *reply
-	if r, err = wd.send("GET", wd.url(urlTemplate, wd.id), nil); err == nil {
+	if r, err = wd.send(name, "GET", wd.url(urlTemplate, wd.sessionID()), nil); err == nil {
 		err = r.readValue(&v)
 	}
 	return
@@ -323,7 +958,7 @@ func (wd *remoteWebDriver) boolCommand(urlTemplate string) (v bool, err error) {
 
 func (wd *remoteWebDriver) Status() (v *Status, err error) {
 	var r *reply
-	if r, err = wd.send("GET", wd.url("/status"), nil); err == nil {
+	if r, err = wd.send("Status", "GET", wd.url("/status"), nil); err == nil {
 		err = r.readValue(&v)
 	}
 	return
@@ -331,7 +966,7 @@ func (wd *remoteWebDriver) Status() (v *Status, err error) {
 
 func (wd *remoteWebDriver) Sessions() (sessions []Session, err error) {
 	var r *reply
-	if r, err = wd.send("GET", wd.url("/sessions"), nil); err == nil {
+	if r, err = wd.send("Sessions", "GET", wd.url("/sessions"), nil); err == nil {
 		err = r.readValue(&sessions)
 	}
 	return
@@ -348,171 +983,422 @@ func (wd *remoteWebDriver) NewSession() (string, error) {
 		return "", err
 	}
 
-	r, err := wd.send("POST", wd.url("/session"), data)
+	r, err := wd.send("NewSession", "POST", wd.url("/session"), data)
 	if err != nil {
 		return "", err
 	}
-	wd.id = r.SessionId
+	id, w3c := r.SessionId, false
+	var sessionCaps Capabilities
+	if id == "" {
+		// W3C servers return the session id inside the value.
+		var v struct {
+			SessionId    string       `json:"sessionId"`
+			Capabilities Capabilities `json:"capabilities"`
+		}
+		if err := r.readValue(&v); err == nil && v.SessionId != "" {
+			id = v.SessionId
+			w3c = true
+			sessionCaps = v.Capabilities
+		}
+	} else {
+		r.readValue(&sessionCaps)
+	}
 
-	return r.SessionId, nil
+	wd.mu.Lock()
+	wd.id, wd.w3c, wd.sessionCaps = id, w3c, sessionCaps
+	// The windows and frames of a previous session are gone with it.
+	wd.frames, wd.tabbed, wd.ownTab, wd.window = nil, false, "", ""
+	wd.mu.Unlock()
+	return id, nil
 }
 
 func (wd *remoteWebDriver) Capabilities() (v Capabilities, err error) {
 	var r *reply
-	if r, err = wd.send("GET", wd.url("/session/%s", wd.id), nil); err == nil {
+	if r, err = wd.send("Capabilities", "GET", wd.url("/session/%s", wd.sessionID()), nil); err == nil {
 		r.readValue(&v)
 	}
 	return
 }
 
+// browserName returns the negotiated browser name, falling back to the
+// requested one.
+func (wd *remoteWebDriver) browserName() string {
+	if name, ok := wd.sessionCaps["browserName"].(string); ok && name != "" {
+		return name
+	}
+	name, _ := wd.capabilities["browserName"].(string)
+	return name
+}
+
+func (wd *remoteWebDriver) SupportsCDP() bool {
+	return wd.isChromium()
+}
+
+func (wd *remoteWebDriver) SupportsPrint() bool {
+	return wd.w3c
+}
+
+func (wd *remoteWebDriver) SupportsActions() bool {
+	return wd.w3c
+}
+
+func (wd *remoteWebDriver) SessionCapabilities() (*SessionInfo, error) {
+	// W3C servers only return the capabilities when creating the session.
+	if wd.w3c {
+		if wd.sessionCaps == nil {
+			return nil, ErrNotSupported
+		}
+		return newSessionInfo(wd.sessionCaps), nil
+	}
+	caps, err := wd.Capabilities()
+	if err != nil {
+		return nil, err
+	}
+	return newSessionInfo(caps), nil
+}
+
 func (wd *remoteWebDriver) GetSessionID() string {
-	return wd.id
+	return wd.sessionID()
+}
+
+// W3C keys for the legacy SetTimeout types.
+var w3cTimeoutKeys = map[string]string{
+	"implicit":  "implicit",
+	"page load": "pageLoad",
+	"script":    "script",
 }
 
 func (wd *remoteWebDriver) SetTimeout(timeoutType string, ms uint) error {
+	if wd.w3c {
+		key, ok := w3cTimeoutKeys[timeoutType]
+		if !ok {
+			return fmt.Errorf("unknown timeout type %q", timeoutType)
+		}
+		return wd.voidCommand("SetTimeout", "/session/%s/timeouts", map[string]uint{key: ms})
+	}
 	params := map[string]interface{}{"type": timeoutType, "ms": ms}
-	return wd.voidCommand("/session/%s/timeouts", params)
+	return wd.voidCommand("SetTimeout", "/session/%s/timeouts", params)
+}
+
+func (wd *remoteWebDriver) SetTimeouts(timeouts Timeouts) error {
+	return wd.voidCommand("SetTimeouts", "/session/%s/timeouts", timeouts)
 }
 
 func (wd *remoteWebDriver) SetAsyncScriptTimeout(ms uint) error {
 	params := map[string]uint{"ms": ms}
-	return wd.voidCommand("/session/%s/timeouts/async_script", params)
+	return wd.voidCommand("SetAsyncScriptTimeout", "/session/%s/timeouts/async_script", params)
 }
 
 func (wd *remoteWebDriver) SetImplicitWaitTimeout(ms uint) error {
 	params := map[string]uint{"ms": ms}
-	return wd.voidCommand("/session/%s/timeouts/implicit_wait", params)
+	return wd.voidCommand("SetImplicitWaitTimeout", "/session/%s/timeouts/implicit_wait", params)
 }
 
 func (wd *remoteWebDriver) AvailableEngines() ([]string, error) {
-	return wd.stringsCommand("/session/%s/ime/available_engines")
+	return wd.stringsCommand("AvailableEngines", "/session/%s/ime/available_engines")
 }
 
 func (wd *remoteWebDriver) ActiveEngine() (string, error) {
-	return wd.stringCommand("/session/%s/ime/active_engine")
+	return wd.stringCommand("ActiveEngine", "/session/%s/ime/active_engine")
 }
 
 func (wd *remoteWebDriver) IsEngineActivated() (bool, error) {
-	return wd.boolCommand("/session/%s/ime/activated")
+	return wd.boolCommand("IsEngineActivated", "/session/%s/ime/activated")
 }
 
 func (wd *remoteWebDriver) DeactivateEngine() error {
-	return wd.voidCommand("session/%s/ime/deactivate", nil)
+	return wd.voidCommand("DeactivateEngine", "/session/%s/ime/deactivate", nil)
 }
 
 func (wd *remoteWebDriver) ActivateEngine(engine string) (err error) {
-	return wd.voidCommand("/session/%s/ime/activate", map[string]string{"engine": engine})
+	return wd.voidCommand("ActivateEngine", "/session/%s/ime/activate", map[string]string{"engine": engine})
 }
 
 func (wd *remoteWebDriver) Quit() (err error) {
-	wd.haveQuitMu.Lock()
-	defer wd.haveQuitMu.Unlock()
-	if wd.haveQuit {
+	wd.quitMu.Lock()
+	defer wd.quitMu.Unlock()
+	id := wd.sessionID()
+	if id == "" || id == wd.quitID {
 		// Double-Quit is an error-free no-op.
 		return nil
 	}
-	wd.haveQuit = true
+	wd.quitID = id
+	if wd.tabOf != nil {
+		return wd.Close()
+	}
 	// Quit is the one method which cannot be canceled.
 	// It's also the last thing that happens in a webdriver, so we can
-	// kill the context here.
+	// kill the contexts here.
+	wd.mu.Lock()
 	wd.ctx = context.Background()
+	wd.cmdCtx = nil
+	wd.mu.Unlock()
 
-	if _, err = wd.execute("DELETE", wd.url("/session/%s", wd.id), nil); err == nil {
-		wd.id = ""
+	if _, err = wd.execute("Quit", "DELETE", wd.url("/session/%s", id), nil); err == nil {
+		wd.mu.Lock()
+		if wd.id == id {
+			wd.id = ""
+		}
+		wd.mu.Unlock()
 	}
 	return
 }
 
+func (wd *remoteWebDriver) DeleteSession() error {
+	return wd.Quit()
+}
+
+func (wd *remoteWebDriver) Restart() error {
+	// The old session may already be gone with its browser, which is often
+	// the reason for restarting.
+	_ = wd.Quit()
+
+	_, err := wd.NewSession()
+	return err
+}
+
 func (wd *remoteWebDriver) CurrentWindowHandle() (string, error) {
-	return wd.stringCommand("/session/%s/window_handle")
+	if wd.w3c {
+		return wd.stringCommand("CurrentWindowHandle", "/session/%s/window")
+	}
+	return wd.stringCommand("CurrentWindowHandle", "/session/%s/window_handle")
 }
 
 func (wd *remoteWebDriver) WindowHandles() ([]string, error) {
-	return wd.stringsCommand("/session/%s/window_handles")
+	if wd.w3c {
+		return wd.stringsCommand("WindowHandles", "/session/%s/window/handles")
+	}
+	return wd.stringsCommand("WindowHandles", "/session/%s/window_handles")
 }
 
 func (wd *remoteWebDriver) CurrentURL() (string, error) {
-	return wd.stringCommand("/session/%s/url")
+	return wd.stringCommand("CurrentURL", "/session/%s/url")
 }
 
 func (wd *remoteWebDriver) Get(url string) error {
-	return wd.voidCommand("/session/%s/url", map[string]string{"url": url})
+	// Navigating returns to the top-level document.
+	return wd.resetFrames(wd.voidCommand("Get", "/session/%s/url", map[string]string{"url": url}))
 }
 
 func (wd *remoteWebDriver) Forward() error {
-	return wd.voidCommand("/session/%s/forward", nil)
+	return wd.resetFrames(wd.voidCommand("Forward", "/session/%s/forward", nil))
 }
 
 func (wd *remoteWebDriver) Back() error {
-	return wd.voidCommand("/session/%s/back", nil)
+	return wd.resetFrames(wd.voidCommand("Back", "/session/%s/back", nil))
 }
 
 func (wd *remoteWebDriver) Refresh() error {
-	return wd.voidCommand("/session/%s/refresh", nil)
+	return wd.resetFrames(wd.voidCommand("Refresh", "/session/%s/refresh", nil))
 }
 
 func (wd *remoteWebDriver) Title() (string, error) {
-	return wd.stringCommand("/session/%s/title")
+	return wd.stringCommand("Title", "/session/%s/title")
 }
 
 func (wd *remoteWebDriver) PageSource() (string, error) {
-	return wd.stringCommand("/session/%s/source")
+	return wd.stringCommand("PageSource", "/session/%s/source")
 }
 
 type element struct {
 	Element string `json:"ELEMENT"`
+	W3C     string `json:"element-6066-11e4-a52e-4f735466cecf,omitempty"`
 }
 
-func (wd *remoteWebDriver) find(by, value, suffix, url string) (r *reply, err error) {
-	params := map[string]string{"using": by, "value": value}
-	var data []byte
-	if data, err = json.Marshal(params); err == nil {
-		if url == "" {
-			url = "/session/%s/element"
-		}
-		urlTemplate := url + suffix
-		url = wd.url(urlTemplate, wd.id)
-		r, err = wd.send("POST", url, data)
+// id returns the element id from either protocol's reference object.
+func (e *element) id() string {
+	if e.Element != "" {
+		return e.Element
 	}
-	return
+	return e.W3C
 }
 
-func decodeElement(wd *remoteWebDriver, r *reply) WebElement {
-	var elem element
-	if err := r.readValue(&elem); err != nil {
-		panic(err.Error() + ": " + string(r.Value))
+// scriptElementID returns the id of v if it is an element reference object,
+// which holds nothing but the JSON Wire and/or W3C element key.
+func scriptElementID(v map[string]interface{}) (string, bool) {
+	if len(v) == 0 || len(v) > 2 {
+		return "", false
+	}
+	var id string
+	for k, val := range v {
+		s, ok := val.(string)
+		if !ok || (k != "ELEMENT" && k != webElementKey) {
+			return "", false
+		}
+		id = s
 	}
-	return &remoteWE{parent: wd, id: elem.Element}
+	return id, true
 }
 
-func (wd *remoteWebDriver) FindElement(by, value string) (WebElement, error) {
-	if res, err := wd.find(by, value, "", ""); err == nil {
-		return decodeElement(wd, res), nil
-	} else {
-		return nil, err
-	}
+// elementRef is the reference object sent for elem, understood by both
+// protocols.
+func elementRef(elem *remoteWE) map[string]string {
+	return map[string]string{"ELEMENT": elem.id, webElementKey: elem.id}
 }
 
-func decodeElements(wd *remoteWebDriver, r *reply) (welems []WebElement) {
-	var elems []element
-	if err := r.readValue(&elems); err != nil {
-		panic(err.Error() + ": " + string(r.Value))
+// encodeScriptArg returns a copy of a script argument with WebElements,
+// however deeply nested in slices and maps, replaced by element references.
+func encodeScriptArg(arg interface{}) interface{} {
+	switch arg := arg.(type) {
+	case *remoteWE:
+		return elementRef(arg)
+	case []WebElement:
+		refs := make([]interface{}, len(arg))
+		for i, elem := range arg {
+			refs[i] = encodeScriptArg(elem)
+		}
+		return refs
+	case []interface{}:
+		values := make([]interface{}, len(arg))
+		for i, v := range arg {
+			values[i] = encodeScriptArg(v)
+		}
+		return values
+	case map[string]interface{}:
+		values := make(map[string]interface{}, len(arg))
+		for k, v := range arg {
+			values[k] = encodeScriptArg(v)
+		}
+		return values
 	}
-	for _, elem := range elems {
-		welems = append(welems, &remoteWE{wd, elem.Element})
+	return arg
+}
+
+// decodeScriptValue replaces the element references in a script result,
+// however deeply nested, with WebElements.
+func (wd *remoteWebDriver) decodeScriptValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case []interface{}:
+		for i := range v {
+			v[i] = wd.decodeScriptValue(v[i])
+		}
+	case map[string]interface{}:
+		if id, ok := scriptElementID(v); ok {
+			return &remoteWE{parent: wd, id: id}
+		}
+		for k := range v {
+			v[k] = wd.decodeScriptValue(v[k])
+		}
 	}
-	return
+	return v
 }
 
-func (wd *remoteWebDriver) FindElements(by, value string) ([]WebElement, error) {
-	if res, err := wd.find(by, value, "s", ""); err == nil {
-		return decodeElements(wd, res), nil
-	} else {
-		return nil, err
+// find posts a locator to urlTemplate, which is formatted with the session
+// id, e.g. "/session/%s/element" or "/session/%s/elements".
+func (wd *remoteWebDriver) find(name, urlTemplate, by, value string) (r *reply, err error) {
+	params := map[string]string{"using": by, "value": value}
+	var data []byte
+	if data, err = json.Marshal(params); err == nil {
+		r, err = wd.send(name, "POST", wd.url(urlTemplate, wd.sessionID()), data)
 	}
+	return
 }
 
-func (wd *remoteWebDriver) Q(sel string) (WebElement, error) {
+// findOne runs a find element command on urlTemplate.
+func (wd *remoteWebDriver) findOne(name, urlTemplate, by, value string) (WebElement, error) {
+	deadline := time.Now().Add(wd.implicitWait)
+	for {
+		r, err := wd.find(name, urlTemplate, by, value)
+		if err == nil {
+			return decodeElement(wd, r)
+		}
+		if !isNoSuchElement(err) || !time.Now().Before(deadline) {
+			return nil, err
+		}
+		time.Sleep(implicitWaitInterval)
+	}
+}
+
+// findMany runs a find elements command on urlTemplate.
+func (wd *remoteWebDriver) findMany(name, urlTemplate, by, value string) ([]WebElement, error) {
+	deadline := time.Now().Add(wd.implicitWait)
+	for {
+		r, err := wd.find(name, urlTemplate, by, value)
+		elems, err := findElements(wd, r, err)
+		if err != nil || len(elems) > 0 || !time.Now().Before(deadline) {
+			return elems, err
+		}
+		time.Sleep(implicitWaitInterval)
+	}
+}
+
+// implicitWaitInterval is how often finds poll with ClientImplicitWait.
+const implicitWaitInterval = 100 * time.Millisecond
+
+func decodeElement(wd *remoteWebDriver, r *reply) (WebElement, error) {
+	if r == nil {
+		return nil, errors.New("empty element reply")
+	}
+	var elem element
+	if err := r.readValue(&elem); err != nil {
+		return nil, fmt.Errorf("bad element reply %s: %s", r.Value, err)
+	}
+	if elem.id() == "" {
+		return nil, fmt.Errorf("bad element reply %s: no element id", r.Value)
+	}
+	return &remoteWE{parent: wd, id: elem.id()}, nil
+}
+
+func (wd *remoteWebDriver) FindElement(by, value string) (WebElement, error) {
+	return wd.findOne("FindElement", "/session/%s/element", by, value)
+}
+
+func decodeElements(wd *remoteWebDriver, r *reply) ([]WebElement, error) {
+	if r == nil {
+		return nil, errors.New("empty elements reply")
+	}
+	var elems []element
+	if err := r.readValue(&elems); err != nil {
+		return nil, fmt.Errorf("bad elements reply %s: %s", r.Value, err)
+	}
+	if elems == nil {
+		return nil, fmt.Errorf("bad elements reply %s: no element list", r.Value)
+	}
+	welems := make([]WebElement, 0, len(elems))
+	for _, elem := range elems {
+		if elem.id() == "" {
+			return nil, fmt.Errorf("bad elements reply %s: no element id", r.Value)
+		}
+		welems = append(welems, &remoteWE{wd, elem.id()})
+	}
+	return welems, nil
+}
+
+// findElements is the result of a find elements command, which is empty
+// rather than an error when nothing matches, as some JSON Wire servers
+// report.
+func findElements(wd *remoteWebDriver, r *reply, err error) ([]WebElement, error) {
+	if code, ok := ErrorCode(err); ok && code == 7 {
+		return []WebElement{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return decodeElements(wd, r)
+}
+
+func (wd *remoteWebDriver) FindElements(by, value string) ([]WebElement, error) {
+	return wd.findMany("FindElements", "/session/%s/elements", by, value)
+}
+
+func (wd *remoteWebDriver) CountElements(by, value string) (int, error) {
+	elems, err := wd.FindElements(by, value)
+	return len(elems), err
+}
+
+func (wd *remoteWebDriver) IsElementPresent(by, value string) (bool, error) {
+	return isPresent(wd.FindElement(by, value))
+}
+
+// isPresent turns the result of a FindElement into whether it found one.
+func isPresent(_ WebElement, err error) (bool, error) {
+	if code, ok := ErrorCode(err); ok && code == 7 {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func (wd *remoteWebDriver) Q(sel string) (WebElement, error) {
 	return wd.FindElement(ByCSSSelector, sel)
 }
 
@@ -520,31 +1406,229 @@ func (wd *remoteWebDriver) QAll(sel string) ([]WebElement, error) {
 	return wd.FindElements(ByCSSSelector, sel)
 }
 
+func (wd *remoteWebDriver) RectsOf(sel string) ([]Rect, error) {
+	script := `return Array.prototype.map.call(document.querySelectorAll(arguments[0]), function(e) {
+		var r = e.getBoundingClientRect();
+		return {x: r.left, y: r.top, width: r.width, height: r.height};
+	});`
+	res, err := wd.ExecuteScript(script, []interface{}{sel})
+	if err != nil {
+		return nil, err
+	}
+	data, err := json.Marshal(res)
+	if err != nil {
+		return nil, err
+	}
+	var rects []Rect
+	err = json.Unmarshal(data, &rects)
+	return rects, err
+}
+
+func (wd *remoteWebDriver) FindBrokenImages() ([]string, error) {
+	script := `var broken = [];
+	Array.prototype.forEach.call(document.images, function(img) {
+		if (img.complete && img.naturalWidth === 0) {
+			broken.push(img.src);
+		}
+	});
+	return broken;`
+	res, err := wd.ExecuteScript(script, nil)
+	if err != nil {
+		return nil, err
+	}
+	srcs := []string{}
+	if list, ok := res.([]interface{}); ok {
+		for _, src := range list {
+			if s, ok := src.(string); ok {
+				srcs = append(srcs, s)
+			}
+		}
+	}
+	return srcs, nil
+}
+
+func (wd *remoteWebDriver) StructuredData() ([]json.RawMessage, error) {
+	script := `var blocks = document.querySelectorAll('script[type="application/ld+json"]');
+	var texts = [];
+	for (var i = 0; i < blocks.length; i++) {
+		texts.push(blocks[i].textContent);
+	}
+	return texts;`
+	res, err := wd.ExecuteScript(script, nil)
+	if err != nil {
+		return nil, err
+	}
+	texts, _ := res.([]interface{})
+	data := make([]json.RawMessage, 0, len(texts))
+	for i, text := range texts {
+		s, _ := text.(string)
+		if !json.Valid([]byte(s)) {
+			return nil, fmt.Errorf("invalid JSON-LD in block %d", i)
+		}
+		data = append(data, json.RawMessage(s))
+	}
+	return data, nil
+}
+
 func (wd *remoteWebDriver) Close() error {
-	_, err := wd.execute("DELETE", wd.url("/session/%s/window", wd.id), nil)
-	return err
+	return wd.command(func() error {
+		if _, err := wd.executeInWindow("Close", "DELETE", wd.url("/session/%s/window", wd.sessionID()), nil); err != nil {
+			return err
+		}
+		// The browser is in no window until the next switch.
+		root := wd.root()
+		root.mu.Lock()
+		root.window = ""
+		root.mu.Unlock()
+		return nil
+	})
+}
+
+func (wd *remoteWebDriver) NewWindow(typ string) (string, error) {
+	if typ == "" {
+		typ = "tab"
+	}
+	data, err := json.Marshal(map[string]string{"type": typ})
+	if err != nil {
+		return "", err
+	}
+	r, err := wd.send("NewWindow", "POST", wd.url("/session/%s/window/new", wd.sessionID()), data)
+	if err != nil {
+		return "", err
+	}
+	var v struct {
+		Handle string `json:"handle"`
+	}
+	if err := r.readValue(&v); err != nil {
+		return "", err
+	}
+	return v.Handle, nil
+}
+
+func (wd *remoteWebDriver) NewTab() (WebDriver, error) {
+	root := wd.root()
+	root.mu.Lock()
+	tabbed := root.tabbed
+	root.mu.Unlock()
+	if !tabbed {
+		// From now on the session driver also has a window of its own.
+		current, err := root.CurrentWindowHandle()
+		if err != nil {
+			return nil, err
+		}
+		root.mu.Lock()
+		root.ownTab, root.window, root.tabbed = current, current, true
+		root.mu.Unlock()
+	}
+
+	handle, err := wd.NewWindow("tab")
+	if err != nil {
+		return nil, err
+	}
+	return &remoteWebDriver{
+		executor:            wd.executor,
+		capabilities:        wd.capabilities,
+		ctx:                 context.Background(),
+		w3c:                 wd.w3c,
+		sessionCaps:         wd.sessionCaps,
+		retries:             wd.retries,
+		retryBackoff:        wd.retryBackoff,
+		commandTimeout:      wd.commandTimeout,
+		logger:              wd.logger,
+		metricsHook:         wd.metricsHook,
+		tracer:              wd.tracer,
+		httpClient:          wd.httpClient,
+		gridURL:             wd.gridURL,
+		keepSessionOnCancel: wd.keepSessionOnCancel,
+		implicitWait:        wd.implicitWait,
+		tabOf:               root,
+		ownTab:              handle,
+	}, nil
+}
+
+func (wd *remoteWebDriver) TabCount() (int, error) {
+	handles, err := wd.WindowHandles()
+	return len(handles), err
+}
+
+func (wd *remoteWebDriver) tabHandle(index int) (string, error) {
+	handles, err := wd.WindowHandles()
+	if err != nil {
+		return "", err
+	}
+	if index < 0 || index >= len(handles) {
+		return "", fmt.Errorf("tab index %d out of range, %d tabs open", index, len(handles))
+	}
+	return handles[index], nil
+}
+
+func (wd *remoteWebDriver) SwitchToTab(index int) error {
+	handle, err := wd.tabHandle(index)
+	if err != nil {
+		return err
+	}
+	return wd.SwitchWindow(handle)
+}
+
+func (wd *remoteWebDriver) CloseTab(index int) error {
+	handle, err := wd.tabHandle(index)
+	if err != nil {
+		return err
+	}
+	current, err := wd.CurrentWindowHandle()
+	if err != nil {
+		return err
+	}
+	if err := wd.SwitchWindow(handle); err != nil {
+		return err
+	}
+	if err := wd.Close(); err != nil {
+		return err
+	}
+	if handle == current {
+		return nil
+	}
+	return wd.SwitchWindow(current)
 }
 
 func (wd *remoteWebDriver) SwitchWindow(name string) error {
+	return wd.command(func() error {
+		if err := wd.inWindowCommand("SwitchWindow", "/session/%s/window", wd.windowParams(name)); err != nil {
+			return err
+		}
+		wd.mu.Lock()
+		wd.ownTab, wd.frames = name, nil
+		wd.mu.Unlock()
+		root := wd.root()
+		root.mu.Lock()
+		root.window = name
+		root.mu.Unlock()
+		return nil
+	})
+}
+
+func (wd *remoteWebDriver) windowParams(name string) map[string]string {
+	if wd.w3c {
+		// W3C only switches by handle, as returned by WindowHandles.
+		return map[string]string{"handle": name}
+	}
 	if name == "" {
 		name = "current"
 	}
-	params := map[string]string{"name": name}
-	return wd.voidCommand("/session/%s/window", params)
+	return map[string]string{"name": name}
 }
 
 func (wd *remoteWebDriver) CloseWindow(name string) error {
-	_, err := wd.execute("DELETE", wd.url("/session/%s/window", wd.id), nil)
-	return err
+	return wd.Close()
 }
 
 func (wd *remoteWebDriver) WindowSize(name string) (sz *Size, err error) {
 	if name == "" {
 		name = "current"
 	}
-	url := wd.url("/session/%s/window/%s/size", wd.id, name)
+	url := wd.url("/session/%s/window/%s/size", wd.sessionID(), name)
 	var r *reply
-	if r, err = wd.send("GET", url, nil); err == nil {
+	if r, err = wd.send("WindowSize", "GET", url, nil); err == nil {
 		err = r.readValue(&sz)
 	}
 	return
@@ -554,48 +1638,143 @@ func (wd *remoteWebDriver) WindowPosition(name string) (pt *Point, err error) {
 	if name == "" {
 		name = "current"
 	}
-	url := wd.url("/session/%s/window/%s/position", wd.id, name)
+	url := wd.url("/session/%s/window/%s/position", wd.sessionID(), name)
 	var r *reply
-	if r, err = wd.send("GET", url, nil); err == nil {
+	if r, err = wd.send("WindowPosition", "GET", url, nil); err == nil {
 		err = r.readValue(&pt)
 	}
 	return
 }
 
+func (wd *remoteWebDriver) CurrentWindowRect() (*Rect, error) {
+	if !wd.w3c {
+		pt, err := wd.WindowPosition("")
+		if err != nil {
+			return nil, err
+		}
+		sz, err := wd.WindowSize("")
+		if err != nil {
+			return nil, err
+		}
+		return &Rect{X: pt.X, Y: pt.Y, Width: sz.Width, Height: sz.Height}, nil
+	}
+	r, err := wd.send("CurrentWindowRect", "GET", wd.url("/session/%s/window/rect", wd.sessionID()), nil)
+	if err != nil {
+		return nil, err
+	}
+	var rect Rect
+	if err := r.readValue(&rect); err != nil {
+		return nil, err
+	}
+	return &rect, nil
+}
+
 func (wd *remoteWebDriver) ResizeWindow(name string, to Size) error {
 	if name == "" {
 		name = "current"
 	}
-	url := wd.url("/session/%s/window/%s/size", wd.id, name)
+	url := wd.url("/session/%s/window/%s/size", wd.sessionID(), name)
 	data, err := json.Marshal(to)
 	if err != nil {
 		return err
 	}
-	_, err = wd.send("POST", url, data)
+	_, err = wd.send("ResizeWindow", "POST", url, data)
 	return err
 }
 
-func (wd *remoteWebDriver) SwitchFrame(frame string) error {
-	params := map[string]string{"id": frame}
-	return wd.voidCommand("/session/%s/frame", params)
+func (wd *remoteWebDriver) SwitchFrame(frame interface{}) error {
+	id, err := wd.frameID(frame)
+	if err != nil {
+		return err
+	}
+	params := map[string]interface{}{"id": id}
+	return wd.command(func() error {
+		if err := wd.inWindowCommand("SwitchFrame", "/session/%s/frame", params); err != nil {
+			return err
+		}
+		wd.mu.Lock()
+		if frame == nil {
+			wd.frames = nil
+		} else {
+			wd.frames = append(wd.frames, switchedFrame{name: frameName(frame), id: id})
+		}
+		wd.mu.Unlock()
+		return nil
+	})
+}
+
+func (wd *remoteWebDriver) SwitchToDefaultContent() error {
+	return wd.SwitchFrame(nil)
 }
 
 func (wd *remoteWebDriver) SwitchFrameParent() error {
-	return wd.voidCommand("/session/%s/frame/parent", nil)
+	return wd.command(func() error {
+		if err := wd.inWindowCommand("SwitchFrameParent", "/session/%s/frame/parent", nil); err != nil {
+			return err
+		}
+		wd.mu.Lock()
+		if len(wd.frames) > 0 {
+			wd.frames = wd.frames[:len(wd.frames)-1]
+		}
+		wd.mu.Unlock()
+		return nil
+	})
 }
 
 func (wd *remoteWebDriver) ActiveElement() (WebElement, error) {
-	url := wd.url("/session/%s/element/active", wd.id)
-	if r, err := wd.send("GET", url, nil); err == nil {
-		return decodeElement(wd, r), nil
+	url := wd.url("/session/%s/element/active", wd.sessionID())
+	if r, err := wd.send("ActiveElement", "GET", url, nil); err == nil {
+		return decodeElement(wd, r)
 	} else {
 		return nil, err
 	}
 }
 
+func (wd *remoteWebDriver) SubmitActiveElement() error {
+	elem, err := wd.ActiveElement()
+	if err != nil {
+		return err
+	}
+	return elem.Submit()
+}
+
+func (wd *remoteWebDriver) TabTo(target WebElement, maxTabs int) error {
+	if maxTabs < 0 {
+		return fmt.Errorf("invalid maxTabs %d", maxTabs)
+	}
+	// Follow focus into shadow roots, where document.activeElement stops at
+	// the host.
+	script := `var active = document.activeElement;
+	while (active && active.shadowRoot && active.shadowRoot.activeElement) {
+		active = active.shadowRoot.activeElement;
+	}
+	return active === arguments[0];`
+	for i := 0; ; i++ {
+		res, err := wd.ExecuteScript(script, []interface{}{target})
+		if err != nil {
+			return err
+		}
+		if focused, _ := res.(bool); focused {
+			return nil
+		}
+		if i == maxTabs {
+			return fmt.Errorf("element not focused after %d tabs", maxTabs)
+		}
+		if err := wd.tab(); err != nil {
+			return err
+		}
+	}
+}
+
+func (wd *remoteWebDriver) tab() error {
+	a := wd.Actions()
+	a.Key("keyboard").KeyDown(TabKey).KeyUp(TabKey)
+	return a.Perform()
+}
+
 func (wd *remoteWebDriver) GetCookies() (c []Cookie, err error) {
 	var r *reply
-	if r, err = wd.send("GET", wd.url("/session/%s/cookie", wd.id), nil); err == nil {
+	if r, err = wd.send("GetCookies", "GET", wd.url("/session/%s/cookie", wd.sessionID()), nil); err == nil {
 		err = r.readValue(&c)
 		if err == nil {
 			parseCookieExpiry(&c, r.Value)
@@ -614,7 +1793,10 @@ func parseCookieExpiry(cookies *[]Cookie, raw json.RawMessage) {
 		return
 	}
 
-	for i, _ := range *cookies {
+	for i := range *cookies {
+		if i >= len(expiries) || expiries[i].Expiry == "" {
+			continue
+		}
 		expiry, err := expiries[i].Expiry.Float64()
 		if err != nil {
 			continue
@@ -624,36 +1806,245 @@ func parseCookieExpiry(cookies *[]Cookie, raw json.RawMessage) {
 	}
 }
 
+// ErrNoSuchCookie is returned by GetNamedCookie when the cookie isn't set.
+var ErrNoSuchCookie = errors.New("no such cookie")
+
+func (wd *remoteWebDriver) GetNamedCookie(name string) (*Cookie, error) {
+	if !wd.w3c {
+		// JSON Wire has no command for a single cookie.
+		cookies, err := wd.GetCookies()
+		if err != nil {
+			return nil, err
+		}
+		for _, c := range cookies {
+			if c.Name == name {
+				return &c, nil
+			}
+		}
+		return nil, ErrNoSuchCookie
+	}
+
+	r, err := wd.send("GetNamedCookie", "GET", wd.url("/session/%s/cookie/%s", wd.sessionID(), url.PathEscape(name)), nil)
+	if e, ok := err.(*Error); ok && e.Code == 62 {
+		return nil, ErrNoSuchCookie
+	} else if err != nil {
+		return nil, err
+	}
+	cookies := make([]Cookie, 1)
+	if err := r.readValue(&cookies[0]); err != nil {
+		return nil, err
+	}
+	parseCookieExpiry(&cookies, json.RawMessage("["+string(r.Value)+"]"))
+	return &cookies[0], nil
+}
+
+// wireCookie is a Cookie as the server expects it, with the expiry in unix
+// seconds.
+type wireCookie struct {
+	*Cookie
+	Expiry int64 `json:"expiry,omitempty"`
+}
+
+func newWireCookie(cookie *Cookie) wireCookie {
+	c := wireCookie{Cookie: cookie}
+	if expires := cookie.ExpiresAt(); !expires.IsZero() {
+		c.Expiry = expires.Unix()
+	}
+	return c
+}
+
 func (wd *remoteWebDriver) AddCookie(cookie *Cookie) error {
-	params := map[string]*Cookie{"cookie": cookie}
-	return wd.voidCommand("/session/%s/cookie", params)
+	params := map[string]interface{}{"cookie": newWireCookie(cookie)}
+	return wd.voidCommand("AddCookie", "/session/%s/cookie", params)
+}
+
+func (wd *remoteWebDriver) ExportCookies() ([]byte, error) {
+	cookies, err := wd.GetCookies()
+	if err != nil {
+		return nil, err
+	}
+	jar := make([]wireCookie, len(cookies))
+	for i := range cookies {
+		jar[i] = newWireCookie(&cookies[i])
+	}
+	return json.Marshal(jar)
+}
+
+func (wd *remoteWebDriver) ImportCookies(data []byte) error {
+	var jar []wireCookie
+	if err := json.Unmarshal(data, &jar); err != nil {
+		return err
+	}
+	for _, c := range jar {
+		if c.Cookie == nil {
+			continue
+		}
+		if c.Expiry > 0 {
+			c.Expires = time.Unix(c.Expiry, 0)
+		}
+		if err := wd.AddCookie(c.Cookie); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (wd *remoteWebDriver) AddHTTPCookie(cookie *http.Cookie) error {
+	return wd.AddCookie(fromHTTPCookie(cookie))
+}
+
+// fromHTTPCookie converts cookie, taking a positive MaxAge as the expiry like
+// the browser would.
+func fromHTTPCookie(cookie *http.Cookie) *Cookie {
+	c := &Cookie{
+		Name:     cookie.Name,
+		Value:    cookie.Value,
+		Path:     cookie.Path,
+		Domain:   cookie.Domain,
+		Secure:   cookie.Secure,
+		HttpOnly: cookie.HttpOnly,
+		Expires:  cookie.Expires,
+	}
+	if c.Path == "" {
+		c.Path = "/"
+	}
+	if cookie.MaxAge > 0 {
+		c.Expires = time.Now().Add(time.Duration(cookie.MaxAge) * time.Second)
+	}
+	switch cookie.SameSite {
+	case http.SameSiteLaxMode:
+		c.SameSite = "Lax"
+	case http.SameSiteStrictMode:
+		c.SameSite = "Strict"
+	case http.SameSiteNoneMode:
+		c.SameSite = "None"
+	}
+	return c
 }
 
 func (wd *remoteWebDriver) DeleteAllCookies() error {
-	_, err := wd.execute("DELETE", wd.url("/session/%s/cookie", wd.id), nil)
+	_, err := wd.execute("DeleteAllCookies", "DELETE", wd.url("/session/%s/cookie", wd.sessionID()), nil)
 	return err
 }
 
 func (wd *remoteWebDriver) DeleteCookie(name string) error {
-	_, err := wd.execute("DELETE", wd.url("/session/%s/cookie/%s", wd.id, name), nil)
+	_, err := wd.execute("DeleteCookie", "DELETE", wd.url("/session/%s/cookie/%s", wd.sessionID(), name), nil)
 	return err
 }
 
 func (wd *remoteWebDriver) Click(button int) error {
 	params := map[string]int{"button": button}
-	return wd.voidCommand("/session/%s/click", params)
+	return wd.voidCommand("Click", "/session/%s/click", params)
 }
 
 func (wd *remoteWebDriver) DoubleClick() error {
-	return wd.voidCommand("/session/%s/doubleclick", nil)
+	return wd.voidCommand("DoubleClick", "/session/%s/doubleclick", nil)
 }
 
 func (wd *remoteWebDriver) ButtonDown() error {
-	return wd.voidCommand("/session/%s/buttondown", nil)
+	return wd.voidCommand("ButtonDown", "/session/%s/buttondown", nil)
 }
 
 func (wd *remoteWebDriver) ButtonUp() error {
-	return wd.voidCommand("/session/%s/buttonup", nil)
+	return wd.voidCommand("ButtonUp", "/session/%s/buttonup", nil)
+}
+
+// touchElementID returns the id of elem, which must be an element of this
+// package for the touch commands.
+func touchElementID(elem WebElement) (string, error) {
+	e, ok := elem.(*remoteWE)
+	if !ok {
+		return "", fmt.Errorf("invalid element %v (%T)", elem, elem)
+	}
+	return e.id, nil
+}
+
+func (wd *remoteWebDriver) touchElement(name, urlTemplate string, elem WebElement) error {
+	id, err := touchElementID(elem)
+	if err != nil {
+		return err
+	}
+	return wd.voidCommand(name, urlTemplate, map[string]string{"element": id})
+}
+
+func (wd *remoteWebDriver) Tap(elem WebElement) error {
+	return wd.touchElement("Tap", "/session/%s/touch/click", elem)
+}
+
+func (wd *remoteWebDriver) DoubleTap(elem WebElement) error {
+	return wd.touchElement("DoubleTap", "/session/%s/touch/doubleclick", elem)
+}
+
+func (wd *remoteWebDriver) LongPress(elem WebElement) error {
+	return wd.touchElement("LongPress", "/session/%s/touch/longclick", elem)
+}
+
+func (wd *remoteWebDriver) TouchDown(x, y int) error {
+	return wd.voidCommand("TouchDown", "/session/%s/touch/down", map[string]int{"x": x, "y": y})
+}
+
+func (wd *remoteWebDriver) TouchUp(x, y int) error {
+	return wd.voidCommand("TouchUp", "/session/%s/touch/up", map[string]int{"x": x, "y": y})
+}
+
+func (wd *remoteWebDriver) TouchMove(x, y int) error {
+	return wd.voidCommand("TouchMove", "/session/%s/touch/move", map[string]int{"x": x, "y": y})
+}
+
+func (wd *remoteWebDriver) TouchScroll(elem WebElement, xOffset, yOffset int) error {
+	params := map[string]interface{}{"xoffset": xOffset, "yoffset": yOffset}
+	if elem != nil {
+		id, err := touchElementID(elem)
+		if err != nil {
+			return err
+		}
+		params["element"] = id
+	}
+	return wd.voidCommand("TouchScroll", "/session/%s/touch/scroll", params)
+}
+
+func (wd *remoteWebDriver) TouchFlick(elem WebElement, xOffset, yOffset, speed int) error {
+	id, err := touchElementID(elem)
+	if err != nil {
+		return err
+	}
+	params := map[string]interface{}{
+		"element": id,
+		"xoffset": xOffset,
+		"yoffset": yOffset,
+		"speed":   speed,
+	}
+	return wd.voidCommand("TouchFlick", "/session/%s/touch/flick", params)
+}
+
+func (wd *remoteWebDriver) Actions() *Actions {
+	return &Actions{wd: wd}
+}
+
+func (wd *remoteWebDriver) ReleaseActions() error {
+	_, err := wd.execute("ReleaseActions", "DELETE", wd.url("/session/%s/actions", wd.sessionID()), nil)
+	return err
+}
+
+func (wd *remoteWebDriver) SetNetworkConnection(bitmask int) error {
+	params := map[string]interface{}{
+		"parameters": map[string]int{"type": bitmask},
+	}
+	err := wd.voidCommand("SetNetworkConnection", "/session/%s/network_connection", params)
+	if isUnknownCommand(err) {
+		return ErrNotSupported
+	}
+	return err
+}
+
+func (wd *remoteWebDriver) NetworkConnection() (v int, err error) {
+	var r *reply
+	if r, err = wd.send("NetworkConnection", "GET", wd.url("/session/%s/network_connection", wd.sessionID()), nil); err == nil {
+		err = r.readValue(&v)
+	} else if isUnknownCommand(err) {
+		err = ErrNotSupported
+	}
+	return
 }
 
 func (wd *remoteWebDriver) SendModifier(modifier string, isDown bool) error {
@@ -667,65 +2058,107 @@ func (wd *remoteWebDriver) SendModifier(modifier string, isDown bool) error {
 		return err
 	}
 
-	return wd.voidCommand("/session/%s/modifier", data)
+	return wd.voidCommand("SendModifier", "/session/%s/modifier", data)
+}
+
+func (wd *remoteWebDriver) SendKeyChord(keys ...string) error {
+	if !wd.w3c {
+		// Modifiers stay down until the null key.
+		params := map[string][]string{"value": append(append([]string{}, keys...), NullKey)}
+		return wd.voidCommand("SendKeyChord", "/session/%s/keys", params)
+	}
+	a := wd.Actions()
+	keyboard := a.Key("keyboard")
+	for _, key := range keys {
+		keyboard.KeyDown(key)
+	}
+	for i := len(keys) - 1; i >= 0; i-- {
+		keyboard.KeyUp(keys[i])
+	}
+	return a.Perform()
+}
+
+// alertPath returns the W3C path of an alert command if the session uses the
+// W3C protocol, and the JSON Wire one otherwise.
+func (wd *remoteWebDriver) alertPath(jsonWire, w3c string) string {
+	if wd.w3c {
+		return "/session/%s/alert/" + w3c
+	}
+	return "/session/%s/" + jsonWire
 }
 
 func (wd *remoteWebDriver) DismissAlert() error {
-	return wd.voidCommand("/session/%s/dismiss_alert", nil)
+	return wd.voidCommand("DismissAlert", wd.alertPath("dismiss_alert", "dismiss"), map[string]string{})
 }
 
 func (wd *remoteWebDriver) AcceptAlert() error {
-	return wd.voidCommand("/session/%s/accept_alert", nil)
+	return wd.voidCommand("AcceptAlert", wd.alertPath("accept_alert", "accept"), map[string]string{})
 }
 
 func (wd *remoteWebDriver) AlertText() (string, error) {
-	return wd.stringCommand("/session/%s/alert_text")
+	return wd.stringCommand("AlertText", wd.alertPath("alert_text", "text"))
 }
 
 func (wd *remoteWebDriver) SetAlertText(text string) error {
 	params := map[string]string{"text": text}
-	return wd.voidCommand("/session/%s/alert_text", params)
+	return wd.voidCommand("SetAlertText", wd.alertPath("alert_text", "text"), params)
 }
 
-func (wd *remoteWebDriver) execScript(script string, args []interface{}, suffix string) (res interface{}, err error) {
-	if args == nil {
-		args = []interface{}{}
-	}
-	for i, arg := range args {
-		if v, ok := arg.(*remoteWE); ok {
-			args[i] = &element{Element: v.id}
-		}
-	}
-	params := map[string]interface{}{
-		"script": script,
-		"args":   args,
-	}
-	var data []byte
-	if data, err = json.Marshal(params); err != nil {
-		return nil, err
+func (wd *remoteWebDriver) SendAlertCredentials(user, password string) error {
+	params := map[string]string{"username": user, "password": password}
+	err := wd.voidCommand("SendAlertCredentials", "/session/%s/alert/credentials", params)
+	if isUnknownCommand(err) {
+		return ErrNotSupported
 	}
-	url := wd.url("/session/%s/execute"+suffix, wd.id)
+	return err
+}
+
+func (wd *remoteWebDriver) execScript(name, script string, args []interface{}, suffix string) (res interface{}, err error) {
 	var r *reply
-	if r, err = wd.send("POST", url, data); err == nil {
+	if r, err = wd.execScriptRaw(name, script, args, suffix); err == nil {
 		err = r.readValue(&res)
 		if err != nil {
 			return
 		}
-
+		res = wd.decodeScriptValue(res)
 	}
 	return
 }
 
+func (wd *remoteWebDriver) execScriptRaw(name, script string, args []interface{}, suffix string) (*reply, error) {
+	if args == nil {
+		args = []interface{}{}
+	}
+	params := map[string]interface{}{
+		"script": script,
+		"args":   encodeScriptArg(args),
+	}
+	data, err := json.Marshal(params)
+	if err != nil {
+		return nil, err
+	}
+	url := wd.url("/session/%s/execute"+suffix, wd.sessionID())
+	return wd.send(name, "POST", url, data)
+}
+
 func (wd *remoteWebDriver) ExecuteScript(script string, args []interface{}) (interface{}, error) {
-	return wd.execScript(script, args, "")
+	return wd.execScript("ExecuteScript", script, args, "")
+}
+
+func (wd *remoteWebDriver) ExecuteScriptInto(script string, args []interface{}, dst interface{}) error {
+	r, err := wd.execScriptRaw("ExecuteScriptInto", script, args, "")
+	if err != nil {
+		return err
+	}
+	return r.readValue(dst)
 }
 
 func (wd *remoteWebDriver) ExecuteScriptAsync(script string, args []interface{}) (interface{}, error) {
-	return wd.execScript(script, args, "_async")
+	return wd.execScript("ExecuteScriptAsync", script, args, "_async")
 }
 
 func (wd *remoteWebDriver) Screenshot() (io.Reader, error) {
-	data, err := wd.stringCommand("/session/%s/screenshot")
+	data, err := wd.stringCommand("Screenshot", "/session/%s/screenshot")
 	if err != nil {
 		return nil, err
 	}
@@ -736,6 +2169,82 @@ func (wd *remoteWebDriver) Screenshot() (io.Reader, error) {
 	return decoder, nil
 }
 
+func (wd *remoteWebDriver) PrintPage(opts PrintOptions) (io.Reader, error) {
+	params := struct {
+		PrintOptions
+		Page map[string]float64 `json:"page,omitempty"`
+	}{PrintOptions: opts}
+	if opts.PageWidth != 0 || opts.PageHeight != 0 {
+		params.Page = make(map[string]float64)
+		if opts.PageWidth != 0 {
+			params.Page["width"] = opts.PageWidth
+		}
+		if opts.PageHeight != 0 {
+			params.Page["height"] = opts.PageHeight
+		}
+	}
+	data, err := json.Marshal(params)
+	if err != nil {
+		return nil, err
+	}
+	r, err := wd.send("PrintPage", "POST", wd.url("/session/%s/print", wd.sessionID()), data)
+	if err != nil {
+		return nil, err
+	}
+	var pdf string
+	if err := r.readValue(&pdf); err != nil {
+		return nil, err
+	}
+	return base64.NewDecoder(base64.StdEncoding, strings.NewReader(pdf)), nil
+}
+
+func (wd *remoteWebDriver) PrintPageToFile(path string, opts PrintOptions) error {
+	pdf, err := wd.PrintPage(opts)
+	if err != nil {
+		return err
+	}
+	data, err := ioutil.ReadAll(pdf)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, data, 0644)
+}
+
+func (wd *remoteWebDriver) UploadFile(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	// The server expects a base64 encoded zip archive holding the file.
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	w, err := zw.Create(filepath.Base(path))
+	if err != nil {
+		return "", err
+	}
+	if _, err = io.Copy(w, f); err != nil {
+		return "", err
+	}
+	if err = zw.Close(); err != nil {
+		return "", err
+	}
+
+	params := map[string]string{"file": base64.StdEncoding.EncodeToString(buf.Bytes())}
+	data, err := json.Marshal(params)
+	if err != nil {
+		return "", err
+	}
+	r, err := wd.send("UploadFile", "POST", wd.url("/session/%s/file", wd.sessionID()), data)
+	if err != nil {
+		return "", err
+	}
+	var remotePath string
+	err = r.readValue(&remotePath)
+	return remotePath, err
+}
+
 func (wd *remoteWebDriver) T(t TestingT) WebDriverT {
 	return &webDriverT{wd, t}
 }
@@ -749,37 +2258,148 @@ type remoteWE struct {
 
 func (elem *remoteWE) Click() error {
 	urlTemplate := fmt.Sprintf("/session/%%s/element/%s/click", elem.id)
-	return elem.parent.voidCommand(urlTemplate, nil)
+	return elem.parent.voidCommand("Click", urlTemplate, nil)
 }
 
-func (elem *remoteWE) SendKeys(keys string) error {
-	chars := make([]string, len(keys))
-	for i, c := range keys {
-		chars[i] = string(c)
+func (elem *remoteWE) JSClick() error {
+	_, err := elem.parent.ExecuteScript("arguments[0].click();", []interface{}{elem})
+	return err
+}
+
+// safeClickWait is how long SafeClick waits for the element to become
+// displayed and enabled.
+const safeClickWait = 2 * time.Second
+
+func (elem *remoteWE) SafeClick() error {
+	if err := elem.ScrollIntoView(); err != nil {
+		return err
 	}
-	params := map[string][]string{"value": chars}
+	deadline := time.Now().Add(safeClickWait)
+	for {
+		displayed, err := elem.IsDisplayed()
+		if err != nil {
+			return err
+		}
+		enabled, err := elem.IsEnabled()
+		if err != nil {
+			return err
+		}
+		if (displayed && enabled) || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	err := elem.Click()
+	if isClickIntercepted(err) {
+		// Usually an animation or a sticky header that moves away.
+		time.Sleep(100 * time.Millisecond)
+		err = elem.Click()
+	}
+	return err
+}
+
+// isClickIntercepted reports whether err is a click that another element
+// would receive, as W3C servers and older chromedrivers report it.
+func isClickIntercepted(err error) bool {
+	e, ok := err.(*Error)
+	return ok && (e.Message == "element click intercepted" ||
+		strings.Contains(e.Details, "is not clickable at point"))
+}
+
+func (elem *remoteWE) DoubleClick() error {
+	wd := elem.parent
+	if wd.w3c {
+		a := wd.Actions()
+		a.Pointer("mouse", MousePointer).PointerMove(elem, 0, 0, 0).
+			PointerDown(LeftButton).PointerUp(LeftButton).
+			PointerDown(LeftButton).PointerUp(LeftButton)
+		return a.Perform()
+	}
+	if err := elem.moveToCenter("DoubleClick"); err != nil {
+		return err
+	}
+	return wd.DoubleClick()
+}
+
+func (elem *remoteWE) ContextClick() error {
+	wd := elem.parent
+	if wd.w3c {
+		a := wd.Actions()
+		a.Pointer("mouse", MousePointer).PointerMove(elem, 0, 0, 0).
+			PointerDown(RightButton).PointerUp(RightButton)
+		return a.Perform()
+	}
+	if err := elem.moveToCenter("ContextClick"); err != nil {
+		return err
+	}
+	return wd.Click(RightButton)
+}
+
+// moveToCenter moves the JSON Wire mouse to the center of elem, which is
+// where moveto goes without offsets.
+func (elem *remoteWE) moveToCenter(name string) error {
+	return elem.parent.voidCommand(name, "/session/%s/moveto", map[string]string{"element": elem.id})
+}
+
+func (elem *remoteWE) SendKeys(keys string) error {
 	urltmpl := fmt.Sprintf("/session/%%s/element/%s/value", elem.id)
-	return elem.parent.voidCommand(urltmpl, params)
+	if elem.parent.w3c {
+		return elem.parent.voidCommand("SendKeys", urltmpl, map[string]string{"text": keys})
+	}
+	// JSON Wire takes the keys one character per entry.
+	chars := make([]string, 0, len(keys))
+	for _, c := range keys {
+		chars = append(chars, string(c))
+	}
+	return elem.parent.voidCommand("SendKeys", urltmpl, map[string][]string{"value": chars})
+}
+
+func (elem *remoteWE) UploadFile(localPath string) error {
+	remotePath, err := elem.parent.UploadFile(localPath)
+	if err != nil {
+		return err
+	}
+	return elem.SendKeys(remotePath)
 }
 
 func (elem *remoteWE) TagName() (string, error) {
 	urlTemplate := fmt.Sprintf("/session/%%s/element/%s/name", elem.id)
-	return elem.parent.stringCommand(urlTemplate)
+	return elem.parent.stringCommand("TagName", urlTemplate)
 }
 
 func (elem *remoteWE) Text() (string, error) {
 	urlTemplate := fmt.Sprintf("/session/%%s/element/%s/text", elem.id)
-	return elem.parent.stringCommand(urlTemplate)
+	return elem.parent.stringCommand("Text", urlTemplate)
+}
+
+func (elem *remoteWE) NormalizedText() (string, error) {
+	text, err := elem.Text()
+	if err != nil {
+		return "", err
+	}
+	return strings.Join(strings.Fields(text), " "), nil
 }
 
 func (elem *remoteWE) Submit() error {
 	urlTemplate := fmt.Sprintf("/session/%%s/element/%s/submit", elem.id)
-	return elem.parent.voidCommand(urlTemplate, nil)
+	return elem.parent.voidCommand("Submit", urlTemplate, nil)
 }
 
 func (elem *remoteWE) Clear() error {
 	urlTemplate := fmt.Sprintf("/session/%%s/element/%s/clear", elem.id)
-	return elem.parent.voidCommand(urlTemplate, nil)
+	return elem.parent.voidCommand("Clear", urlTemplate, nil)
+}
+
+func (elem *remoteWE) SendKeyChord(keys ...string) error {
+	// Both protocols hold typed modifiers down until the null key.
+	return elem.SendKeys(strings.Join(keys, "") + NullKey)
+}
+
+func (elem *remoteWE) ClearAndSendKeys(keys string) error {
+	if err := elem.Clear(); err != nil {
+		return err
+	}
+	return elem.SendKeys(keys)
 }
 
 func (elem *remoteWE) MoveTo(xOffset, yOffset int) error {
@@ -788,15 +2408,15 @@ func (elem *remoteWE) MoveTo(xOffset, yOffset int) error {
 		"xoffset": xOffset,
 		"yoffset": yOffset,
 	}
-	return elem.parent.voidCommand("/session/%s/moveto", params)
+	return elem.parent.voidCommand("MoveTo", "/session/%s/moveto", params)
 }
 
 func (elem *remoteWE) FindElement(by, value string) (WebElement, error) {
-	res, err := elem.parent.find(by, value, "", fmt.Sprintf("/session/%%s/element/%s/element", elem.id))
-	if err != nil {
-		return nil, err
-	}
-	return decodeElement(elem.parent, res), nil
+	return elem.parent.findOne("FindElement", fmt.Sprintf("/session/%%s/element/%s/element", elem.id), by, value)
+}
+
+func (elem *remoteWE) IsElementPresent(by, value string) (bool, error) {
+	return isPresent(elem.FindElement(by, value))
 }
 
 func (elem *remoteWE) Q(sel string) (WebElement, error) {
@@ -808,70 +2428,275 @@ func (elem *remoteWE) QAll(sel string) ([]WebElement, error) {
 }
 
 func (elem *remoteWE) FindElements(by, value string) ([]WebElement, error) {
-	res, err := elem.parent.find(by, value, "s", fmt.Sprintf("/session/%%s/element/%s/element", elem.id))
-	if err != nil {
-		return nil, err
-	}
-	return decodeElements(elem.parent, res), nil
+	return elem.parent.findMany("FindElements", fmt.Sprintf("/session/%%s/element/%s/elements", elem.id), by, value)
 }
 
-func (elem *remoteWE) boolQuery(urlTemplate string) (bool, error) {
+func (elem *remoteWE) boolQuery(name, urlTemplate string) (bool, error) {
 	url := fmt.Sprintf(urlTemplate, elem.id)
-	return elem.parent.boolCommand(url)
+	return elem.parent.boolCommand(name, url)
 }
 
 // Porperties
 func (elem *remoteWE) IsSelected() (bool, error) {
-	return elem.boolQuery("/session/%%s/element/%s/selected")
+	return elem.boolQuery("IsSelected", "/session/%%s/element/%s/selected")
 }
 
 func (elem *remoteWE) IsEnabled() (bool, error) {
-	return elem.boolQuery("/session/%%s/element/%s/enabled")
+	return elem.boolQuery("IsEnabled", "/session/%%s/element/%s/enabled")
 }
 
 func (elem *remoteWE) IsDisplayed() (bool, error) {
-	return elem.boolQuery("/session/%%s/element/%s/displayed")
+	return elem.boolQuery("IsDisplayed", "/session/%%s/element/%s/displayed")
+}
+
+func (elem *remoteWE) RenderedLines() ([]string, error) {
+	res, err := elem.parent.ExecuteScript("return arguments[0].innerText;", []interface{}{elem})
+	if err != nil {
+		return nil, err
+	}
+	text, ok := res.(string)
+	if !ok {
+		return nil, fmt.Errorf("unexpected innerText %v (%T)", res, res)
+	}
+	var lines []string
+	for _, line := range strings.Split(text, "\n") {
+		// Keep the indentation, e.g. of <pre> blocks.
+		if strings.TrimSpace(line) != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines, nil
+}
+
+func (elem *remoteWE) VisibilityReason() (string, error) {
+	script := `var elem = arguments[0];
+	var style = window.getComputedStyle(elem);
+	if (style.visibility === "hidden" || style.visibility === "collapse") {
+		return "visibility: " + style.visibility;
+	}
+	for (var e = elem; e && e.nodeType === 1; e = e.parentElement) {
+		style = window.getComputedStyle(e);
+		var which = e === elem ? "" : "ancestor <" + e.tagName.toLowerCase() + "> has ";
+		if (style.display === "none") {
+			return which + "display: none";
+		}
+		if (style.opacity === "0") {
+			return which + "opacity: 0";
+		}
+	}
+	var rect = elem.getBoundingClientRect();
+	if (rect.width === 0 || rect.height === 0) {
+		return "zero size";
+	}
+	if (rect.right < 0 || rect.bottom < 0) {
+		return "off-screen";
+	}
+	return "visible";`
+	res, err := elem.parent.ExecuteScript(script, []interface{}{elem})
+	if err != nil {
+		return "", err
+	}
+	reason, ok := res.(string)
+	if !ok {
+		return "", fmt.Errorf("unexpected visibility reason %v (%T)", res, res)
+	}
+	return reason, nil
 }
 
 func (elem *remoteWE) GetAttribute(name string) (string, error) {
 	template := "/session/%%s/element/%s/attribute/%s"
 	urlTemplate := fmt.Sprintf(template, elem.id, name)
 
-	return elem.parent.stringCommand(urlTemplate)
+	return elem.parent.stringCommand("GetAttribute", urlTemplate)
 }
 
-func (elem *remoteWE) location(suffix string) (pt *Point, err error) {
+func (elem *remoteWE) GetProperty(name string) (interface{}, error) {
+	wd := elem.parent
+	r, err := wd.send("GetProperty", "GET", wd.url("/session/%s/element/%s/property/%s", wd.sessionID(), elem.id, name), nil)
+	if isUnknownCommand(err) {
+		// JSON Wire has no property command.
+		return wd.ExecuteScript("return arguments[0][arguments[1]];", []interface{}{elem, name})
+	} else if err != nil {
+		return nil, err
+	}
+	var v interface{}
+	if err := r.readValue(&v); err != nil {
+		return nil, err
+	}
+	return wd.decodeScriptValue(v), nil
+}
+
+func (elem *remoteWE) stringProperty(name string) (string, error) {
+	v, err := elem.GetProperty(name)
+	if err != nil {
+		return "", err
+	}
+	s, ok := v.(string)
+	if !ok {
+		return "", fmt.Errorf("unexpected %s property %v (%T)", name, v, v)
+	}
+	return s, nil
+}
+
+func (elem *remoteWE) InnerHTML() (string, error) {
+	return elem.stringProperty("innerHTML")
+}
+
+func (elem *remoteWE) OuterHTML() (string, error) {
+	return elem.stringProperty("outerHTML")
+}
+
+func (elem *remoteWE) HasAttribute(name string) (bool, error) {
+	script := "return arguments[0].hasAttribute(arguments[1])"
+	res, err := elem.parent.ExecuteScript(script, []interface{}{elem, name})
+	if err != nil {
+		return false, err
+	}
+	has, ok := res.(bool)
+	if !ok {
+		return false, fmt.Errorf("unexpected hasAttribute result %v (%T)", res, res)
+	}
+	return has, nil
+}
+
+func (elem *remoteWE) Attributes() (map[string]string, error) {
+	script := `var attrs = {};
+	for (var i = 0; i < arguments[0].attributes.length; i++) {
+		var attr = arguments[0].attributes[i];
+		attrs[attr.name] = attr.value;
+	}
+	return attrs;`
+	return elem.scriptAttributes(script, []interface{}{elem})
+}
+
+func (elem *remoteWE) GetAttributes(names ...string) (map[string]string, error) {
+	script := `var attrs = {};
+	for (var i = 1; i < arguments.length; i++) {
+		var value = arguments[0].getAttribute(arguments[i]);
+		if (value !== null) {
+			attrs[arguments[i]] = value;
+		}
+	}
+	return attrs;`
+	args := []interface{}{elem}
+	for _, name := range names {
+		args = append(args, name)
+	}
+	return elem.scriptAttributes(script, args)
+}
+
+// scriptAttributes runs script, which returns an object of attribute values
+// by name, with args.
+func (elem *remoteWE) scriptAttributes(script string, args []interface{}) (map[string]string, error) {
+	res, err := elem.parent.ExecuteScript(script, args)
+	if err != nil {
+		return nil, err
+	}
+	values, ok := res.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("unexpected attributes %v (%T)", res, res)
+	}
+	attrs := make(map[string]string, len(values))
+	for name, v := range values {
+		value, ok := v.(string)
+		if !ok {
+			return nil, fmt.Errorf("unexpected %s attribute %v (%T)", name, v, v)
+		}
+		attrs[name] = value
+	}
+	return attrs, nil
+}
+
+func (elem *remoteWE) location(name, suffix string) (pt *Point, err error) {
 	wd := elem.parent
 	path := "/session/%s/element/%s/location" + suffix
-	url := wd.url(path, wd.id, elem.id)
+	url := wd.url(path, wd.sessionID(), elem.id)
 	var r *reply
-	if r, err = wd.send("GET", url, nil); err == nil {
+	if r, err = wd.send(name, "GET", url, nil); err == nil {
 		err = r.readValue(&pt)
 	}
 	return
 }
 
 func (elem *remoteWE) Location() (*Point, error) {
-	return elem.location("")
+	return elem.location("Location", "")
 }
 
 func (elem *remoteWE) LocationInView() (*Point, error) {
-	return elem.location("_in_view")
+	return elem.location("LocationInView", "_in_view")
 }
 
 func (elem *remoteWE) Size() (sz *Size, err error) {
 	wd := elem.parent
-	url := wd.url("/session/%s/element/%s/size", wd.id, elem.id)
+	url := wd.url("/session/%s/element/%s/size", wd.sessionID(), elem.id)
 	var r *reply
-	if r, err = wd.send("GET", url, nil); err == nil {
+	if r, err = wd.send("Size", "GET", url, nil); err == nil {
 		err = r.readValue(&sz)
 	}
 	return
 }
 
+func (elem *remoteWE) Rect() (*Rect, error) {
+	wd := elem.parent
+	if !wd.w3c {
+		pt, err := elem.Location()
+		if err != nil {
+			return nil, err
+		}
+		sz, err := elem.Size()
+		if err != nil {
+			return nil, err
+		}
+		return &Rect{X: pt.X, Y: pt.Y, Width: sz.Width, Height: sz.Height}, nil
+	}
+	r, err := wd.send("Rect", "GET", wd.url("/session/%s/element/%s/rect", wd.sessionID(), elem.id), nil)
+	if err != nil {
+		return nil, err
+	}
+	var rect Rect
+	if err := r.readValue(&rect); err != nil {
+		return nil, err
+	}
+	return &rect, nil
+}
+
 func (elem *remoteWE) CSSProperty(name string) (string, error) {
 	urlTemplate := fmt.Sprintf("/session/%%s/element/%s/css/%s", elem.id, name)
-	return elem.parent.stringCommand(urlTemplate)
+	return elem.parent.stringCommand("CSSProperty", urlTemplate)
+}
+
+func (elem *remoteWE) SelectText() error {
+	script := `var range = document.createRange();
+	range.selectNodeContents(arguments[0]);
+	var selection = window.getSelection();
+	selection.removeAllRanges();
+	selection.addRange(range);`
+	_, err := elem.parent.ExecuteScript(script, []interface{}{elem})
+	return err
+}
+
+func (elem *remoteWE) ScrollIntoView() error {
+	script := `var rect = arguments[0].getBoundingClientRect();
+	if (rect.top < 0 || rect.left < 0 ||
+		rect.bottom > window.innerHeight || rect.right > window.innerWidth) {
+		arguments[0].scrollIntoView({block: "center", inline: "center"});
+	}`
+	_, err := elem.parent.ExecuteScript(script, []interface{}{elem})
+	return err
+}
+
+func (elem *remoteWE) Screenshot(scroll bool) (io.Reader, error) {
+	if scroll {
+		if err := elem.ScrollIntoView(); err != nil {
+			return nil, err
+		}
+	}
+	wd := elem.parent
+	data, err := wd.stringCommand("Screenshot", fmt.Sprintf("/session/%%s/element/%s/screenshot", elem.id))
+	if err != nil {
+		return nil, err
+	}
+	return base64.NewDecoder(base64.StdEncoding, strings.NewReader(data)), nil
 }
 
 func (elem *remoteWE) T(t TestingT) WebElementT {
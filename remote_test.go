@@ -1,10 +1,14 @@
 package selenium
 
 import (
+	"encoding/json"
 	"flag"
 	"fmt"
+	"image/png"
 	"io/ioutil"
 	"net/http"
+	"net/http/httptest"
+	"reflect"
 	"strings"
 	"testing"
 	"time"
@@ -46,8 +50,9 @@ func TestStatus(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	if status.OS.Name == "" {
-		t.Fatal("No OS")
+	// W3C servers report readiness, JSON Wire servers the OS.
+	if !status.Ready && status.OS.Name == "" {
+		t.Fatalf("Bad status %+v", status)
 	}
 }
 
@@ -294,6 +299,45 @@ func TestFindElements(t *testing.T) {
 	testFindElements(t, wd, ByCSSSelector, "ol.list li", []string{"foo", "bar"})
 }
 
+func TestFindElements_None(t *testing.T) {
+	t.Parallel()
+	wd := newRemote("TestFindElements_None", t)
+	defer wd.Quit()
+
+	if err := wd.Get(serverURL); err != nil {
+		t.Fatal(err)
+	}
+	elems, err := wd.FindElements(ByClassName, "no-such-class")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if elems == nil || len(elems) != 0 {
+		t.Errorf("FindElements returned %#v, want an empty slice", elems)
+	}
+	if n, err := wd.CountElements(ByCSSSelector, "ol li"); err != nil || n != 4 {
+		t.Errorf("CountElements returned %d, %v, want 4", n, err)
+	}
+}
+
+func TestWaitForElementGone(t *testing.T) {
+	t.Parallel()
+	wd := newRemote("TestWaitForElementGone", t)
+	defer wd.Quit()
+
+	if err := wd.Get(serverURL + "spinner"); err != nil {
+		t.Fatal(err)
+	}
+	if err := wd.WaitForElementGone(ById, "spinner", 100*time.Millisecond, 20*time.Millisecond); err != ErrWaitTimeout {
+		t.Errorf("WaitForElementGone returned %v before the spinner was removed, want %v", err, ErrWaitTimeout)
+	}
+	if err := wd.WaitForElementGone(ById, "spinner", 5*time.Second, 50*time.Millisecond); err != nil {
+		t.Fatalf("WaitForElementGone returned error: %v", err)
+	}
+	if present, err := wd.IsElementPresent(ById, "spinner"); err != nil || present {
+		t.Errorf("IsElementPresent = %t, %v after WaitForElementGone", present, err)
+	}
+}
+
 func TestFindChildElements(t *testing.T) {
 	t.Parallel()
 	wd := newRemote("TestFindChildElements", t).T(t)
@@ -316,6 +360,44 @@ func testFindElements(t *testing.T, ef elementFinder, by, value string, elemsTxt
 	}
 }
 
+func TestRectsOf(t *testing.T) {
+	t.Parallel()
+	wd := newRemote("TestRectsOf", t)
+	defer wd.Quit()
+
+	if err := wd.Get(serverURL); err != nil {
+		t.Fatal(err)
+	}
+	rects, err := wd.RectsOf("ol li")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rects) != 4 {
+		t.Fatalf("Wrong number of rects %d (should be 4)", len(rects))
+	}
+	if rects[0].Y >= rects[1].Y {
+		t.Fatalf("First item %+v not above second item %+v", rects[0], rects[1])
+	}
+}
+
+func TestFindBrokenImages(t *testing.T) {
+	t.Parallel()
+	wd := newRemote("TestFindBrokenImages", t)
+	defer wd.Quit()
+
+	if err := wd.Get(serverURL + "images"); err != nil {
+		t.Fatal(err)
+	}
+	broken, err := wd.FindBrokenImages()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{serverURL + "missing.png"}
+	if !reflect.DeepEqual(broken, want) {
+		t.Fatalf("got broken images %q, want %q", broken, want)
+	}
+}
+
 func TestSendKeys(t *testing.T) {
 	t.Parallel()
 	wd := newRemote("TestSendKeys", t).T(t)
@@ -334,6 +416,51 @@ func TestSendKeys(t *testing.T) {
 	}
 }
 
+func TestSubmitActiveElement(t *testing.T) {
+	t.Parallel()
+	wd := newRemote("TestSubmitActiveElement", t).T(t)
+	defer wd.Quit()
+
+	wd.Get(serverURL)
+	input := wd.FindElement(ByName, "q")
+	input.Click()
+	input.SendKeys("golang")
+	if err := wd.WebDriver().SubmitActiveElement(); err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(wd.PageSource(), "golang") {
+		t.Fatal("Can't find search query in source")
+	}
+}
+
+func TestActiveElement(t *testing.T) {
+	t.Parallel()
+	wd := newRemote("TestActiveElement", t).T(t)
+	defer wd.Quit()
+
+	wd.Get(serverURL)
+	wd.FindElement(ByName, "q").Click()
+	if name := wd.ActiveElement().GetAttribute("name"); name != "q" {
+		t.Errorf("ActiveElement is named %q, want %q", name, "q")
+	}
+}
+
+func TestClearAndSendKeys(t *testing.T) {
+	t.Parallel()
+	wd := newRemote("TestClearAndSendKeys", t).T(t)
+	defer wd.Quit()
+
+	wd.Get(serverURL)
+	input := wd.FindElement(ByName, "q")
+	input.SendKeys("python")
+	input.ClearAndSendKeys("golang")
+
+	if value := input.GetAttribute("value"); value != "golang" {
+		t.Fatalf("Input value is %q, want %q", value, "golang")
+	}
+}
+
 func TestClick(t *testing.T) {
 	t.Parallel()
 	wd := newRemote("TestClick", t).T(t)
@@ -413,6 +540,119 @@ func TestAddCookie(t *testing.T) {
 	t.Fatal("Can't find new cookie")
 }
 
+func TestAcceptInsecureCerts(t *testing.T) {
+	t.Parallel()
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "<html><head><title>Self-signed</title></head></html>")
+	}))
+	defer server.Close()
+
+	for _, accept := range []bool{true, false} {
+		insecureCaps := NewCapabilities().AcceptInsecureCerts(accept)
+		for k, v := range caps {
+			insecureCaps[k] = v
+		}
+		wd, err := NewRemote(insecureCaps, *executor)
+		if err != nil {
+			t.Fatalf("can't start session for test TestAcceptInsecureCerts: %s", err)
+		}
+
+		err = wd.Get(server.URL)
+		title := ""
+		if err == nil {
+			title, err = wd.Title()
+		}
+		loaded := err == nil && title == "Self-signed"
+		wd.Quit()
+
+		if accept && !loaded {
+			t.Errorf("Get with acceptInsecureCerts did not load the page: %q, %v", title, err)
+		}
+		if !accept && loaded {
+			t.Error("Get without acceptInsecureCerts loaded a page with a self-signed certificate")
+		}
+	}
+}
+
+func TestSetLocation(t *testing.T) {
+	if *browserName != "chrome" {
+		t.Skip("permissions are only granted on chrome")
+	}
+	t.Parallel()
+	wd := newRemote("TestSetLocation", t)
+	defer wd.Quit()
+
+	if err := wd.Get(serverURL); err != nil {
+		t.Fatal(err)
+	}
+	if err := wd.SetLocation(Location{Latitude: 48.8584, Longitude: 2.2945}); err != nil {
+		t.Fatal(err)
+	}
+	script := `var done = arguments[0];
+	navigator.geolocation.getCurrentPosition(function(pos) {
+		done(pos.coords.latitude);
+	}, function(err) {
+		done(err.message);
+	});`
+	res, err := wd.ExecuteScriptAsync(script, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res != 48.8584 {
+		t.Errorf("Got latitude %v, want %v", res, 48.8584)
+	}
+}
+
+func TestGetAllCookiesCDP(t *testing.T) {
+	if *browserName != "chrome" {
+		t.Skip("CDP is only supported on chrome")
+	}
+	t.Parallel()
+	wd := newRemote("TestGetAllCookiesCDP", t)
+	defer wd.Quit()
+
+	if err := wd.Get(serverURL + "httponly"); err != nil {
+		t.Fatal(err)
+	}
+	cookies, err := wd.GetAllCookiesCDP()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range cookies {
+		if c.Name == "private" {
+			if !c.HttpOnly {
+				t.Error("HttpOnly not set")
+			}
+			return
+		}
+	}
+	t.Fatal("Can't find HttpOnly cookie")
+}
+
+func TestAddCookie_SameSite(t *testing.T) {
+	t.Parallel()
+	wd := newRemote("TestAddCookie_SameSite", t).T(t)
+	defer wd.Quit()
+
+	wd.Get(serverURL)
+	cookie := &Cookie{Name: "strict", Value: "same site", SameSite: "Strict", HttpOnly: true}
+	wd.AddCookie(cookie)
+
+	for _, c := range wd.GetCookies() {
+		if c.Name == cookie.Name {
+			if c.SameSite != "Strict" {
+				t.Errorf("got SameSite %q, want %q", c.SameSite, "Strict")
+			}
+			if !c.HttpOnly {
+				t.Error("HttpOnly not set")
+			}
+			return
+		}
+	}
+
+	t.Fatal("Can't find new cookie")
+}
+
 func TestDeleteAllCookies(t *testing.T) {
 	t.Parallel()
 	wd := newRemote("TestDeleteCookie", t).T(t)
@@ -536,6 +776,121 @@ func TestScreenshot(t *testing.T) {
 	}
 }
 
+func TestElementScreenshot(t *testing.T) {
+	t.Parallel()
+	wd := newRemote("TestElementScreenshot", t)
+	defer wd.Quit()
+
+	if err := wd.Get(serverURL + "visibility"); err != nil {
+		t.Fatal(err)
+	}
+	elem, err := wd.FindElement(ById, "below")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := elem.Screenshot(true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	img, err := png.Decode(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if size := img.Bounds().Size(); size.X != 100 || size.Y != 50 {
+		t.Errorf("Screenshot is %dx%d, want 100x50", size.X, size.Y)
+	}
+}
+
+func TestElementT(t *testing.T) {
+	t.Parallel()
+	wd := newRemote("TestElementT", t).T(t)
+	defer wd.Quit()
+
+	wd.Get(serverURL + "visibility")
+	elem := wd.FindElement(ById, "below")
+	elem.ScrollIntoView()
+	if rect := elem.Rect(); rect.Width != 100 || rect.Height != 50 {
+		t.Errorf("Rect is %+v, want 100x50", rect)
+	}
+	if id := elem.GetProperty("id"); id != "below" {
+		t.Errorf("GetProperty(\"id\") = %v, want %q", id, "below")
+	}
+	if size := elem.ScreenshotImage(false).Bounds().Size(); size.X != 100 || size.Y != 50 {
+		t.Errorf("ScreenshotImage is %dx%d, want 100x50", size.X, size.Y)
+	}
+}
+
+func TestSwitchWindow(t *testing.T) {
+	t.Parallel()
+	wd := newRemote("TestSwitchWindow", t)
+	defer wd.Quit()
+
+	first, err := wd.CurrentWindowHandle()
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := wd.NewWindow("window")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := wd.SwitchWindow(second); err != nil {
+		t.Fatal(err)
+	}
+	if handle, err := wd.CurrentWindowHandle(); err != nil || handle != second {
+		t.Errorf("CurrentWindowHandle returned %q, %v, want %q", handle, err, second)
+	}
+	if err := wd.SwitchWindow(first); err != nil {
+		t.Fatal(err)
+	}
+	if handle, err := wd.CurrentWindowHandle(); err != nil || handle != first {
+		t.Errorf("CurrentWindowHandle returned %q, %v, want %q", handle, err, first)
+	}
+}
+
+func TestTabs(t *testing.T) {
+	t.Parallel()
+	wd := newRemote("TestTabs", t)
+	defer wd.Quit()
+
+	if err := wd.Get(serverURL); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := wd.NewWindow("tab"); err != nil {
+		t.Fatal(err)
+	}
+	if err := wd.SwitchToTab(1); err != nil {
+		t.Fatal(err)
+	}
+	if err := wd.Get(serverURL + "other"); err != nil {
+		t.Fatal(err)
+	}
+	if n, err := wd.TabCount(); err != nil || n != 2 {
+		t.Fatalf("TabCount returned %d, %v, want 2", n, err)
+	}
+
+	for index, want := range []string{serverURL, serverURL + "other"} {
+		if err := wd.SwitchToTab(index); err != nil {
+			t.Fatal(err)
+		}
+		if url, err := wd.CurrentURL(); err != nil || url != want {
+			t.Errorf("Tab %d has URL %q, %v, want %q", index, url, err, want)
+		}
+	}
+
+	if err := wd.SwitchToTab(0); err != nil {
+		t.Fatal(err)
+	}
+	if err := wd.CloseTab(1); err != nil {
+		t.Fatal(err)
+	}
+	if n, err := wd.TabCount(); err != nil || n != 1 {
+		t.Errorf("TabCount returned %d, %v, want 1", n, err)
+	}
+	if err := wd.SwitchToTab(1); err == nil {
+		t.Error("SwitchToTab(1) returned no error with one tab open")
+	}
+}
+
 func TestIsSelected(t *testing.T) {
 	t.Parallel()
 	wd := newRemote("TestIsSelected", t).T(t)
@@ -556,6 +911,385 @@ func TestIsSelected(t *testing.T) {
 	}
 }
 
+func TestHasAttribute(t *testing.T) {
+	t.Parallel()
+	wd := newRemote("TestHasAttribute", t).T(t)
+	defer wd.Quit()
+
+	wd.Get(serverURL)
+	elem := wd.FindElement(ByName, "hidden_name").WebElement()
+
+	if has, err := elem.HasAttribute("data-flag"); err != nil {
+		t.Fatal(err)
+	} else if !has {
+		t.Fatal("Present empty attribute not found")
+	}
+	if has, err := elem.HasAttribute("data-missing"); err != nil {
+		t.Fatal(err)
+	} else if has {
+		t.Fatal("Absent attribute found")
+	}
+}
+
+func TestAttributes(t *testing.T) {
+	t.Parallel()
+	wd := newRemote("TestAttributes", t).T(t)
+	defer wd.Quit()
+
+	wd.Get(serverURL)
+	attrs, err := wd.FindElement(ByName, "hidden_name").WebElement().Attributes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{"type": "hidden", "name": "hidden_name", "data-flag": ""}
+	if !reflect.DeepEqual(attrs, want) {
+		t.Errorf("Attributes = %v, want %v", attrs, want)
+	}
+}
+
+func TestPinch(t *testing.T) {
+	if *browserName != "chrome" {
+		t.Skip("touch actions are only supported on chrome")
+	}
+	t.Parallel()
+	wd := newRemote("TestPinch", t)
+	defer wd.Quit()
+
+	if err := wd.Get(serverURL + "touch"); err != nil {
+		t.Fatal(err)
+	}
+	elem, err := wd.FindElement(ById, "zoom")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := wd.Actions().Pinch(elem, 2).Perform(); err != nil {
+		t.Fatal(err)
+	}
+	res, err := wd.ExecuteScript("return window.pinchScale", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if scale, _ := res.(float64); scale < 1.5 {
+		t.Fatalf("Bad pinch scale %v, want about 2", res)
+	}
+}
+
+func TestDragAcrossFrames(t *testing.T) {
+	if *browserName != "chrome" {
+		t.Skip("only tested on chrome")
+	}
+	t.Parallel()
+	wd := newRemote("TestDragAcrossFrames", t)
+	defer wd.Quit()
+
+	if err := wd.Get(serverURL + "drag"); err != nil {
+		t.Fatal(err)
+	}
+	source, err := wd.FindElement(ById, "item")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var target WebElement
+	err = InFrame(wd, "frame", func() error {
+		target, err = wd.FindElement(ById, "dropzone")
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := DragAcrossFrames(wd, source, "frame", target); err != nil {
+		t.Fatal(err)
+	}
+
+	var text string
+	err = InFrame(wd, "frame", func() error {
+		text, err = target.Text()
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if text != "Dropped" {
+		t.Errorf("Dropzone text is %q, want %q", text, "Dropped")
+	}
+}
+
+func TestSelectOptions(t *testing.T) {
+	t.Parallel()
+	wd := newRemote("TestSelectOptions", t).T(t)
+	defer wd.Quit()
+
+	wd.Get(serverURL)
+	sel := NewSelect(wd.FindElement(ByName, "color").WebElement())
+
+	options, err := sel.Options()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Option{
+		{Text: "Red", Value: "r"},
+		{Text: "Green", Value: "g", Selected: true},
+		{Text: "Blue", Value: "b"},
+	}
+	if !reflect.DeepEqual(options, want) {
+		t.Fatalf("got options %+v, want %+v", options, want)
+	}
+}
+
+func TestPerformanceMetrics(t *testing.T) {
+	if *browserName != "chrome" {
+		t.Skip("CDP is only supported on chrome")
+	}
+	t.Parallel()
+	wd := newRemote("TestPerformanceMetrics", t)
+	defer wd.Quit()
+
+	if err := wd.Get(serverURL); err != nil {
+		t.Fatal(err)
+	}
+	metrics, err := wd.PerformanceMetrics()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if heap := metrics["JSHeapUsedSize"]; heap <= 0 {
+		t.Fatalf("Bad JSHeapUsedSize: %v", heap)
+	}
+}
+
+func TestCaptureSnapshotMHTML(t *testing.T) {
+	if *browserName != "chrome" {
+		t.Skip("CDP is only supported on chrome")
+	}
+	t.Parallel()
+	wd := newRemote("TestCaptureSnapshotMHTML", t)
+	defer wd.Quit()
+
+	if err := wd.Get(serverURL); err != nil {
+		t.Fatal(err)
+	}
+	mhtml, err := wd.CaptureSnapshotMHTML()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(mhtml, "Go Selenium Test Suite") {
+		t.Fatalf("Page title not in snapshot\n%s", mhtml)
+	}
+}
+
+func TestInterceptRequests(t *testing.T) {
+	if *browserName != "chrome" {
+		t.Skip("CDP is only supported on chrome")
+	}
+	t.Parallel()
+	wd := newRemote("TestInterceptRequests", t)
+	defer wd.Quit()
+
+	stop, err := wd.InterceptRequests("*/api/data", func(req InterceptedRequest) InterceptResponse {
+		return InterceptResponse{
+			Status:  200,
+			Headers: map[string]string{"Content-Type": "application/json"},
+			Body:    []byte(`{"name": "mocked"}`),
+		}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stop()
+
+	if err := wd.Get(serverURL + "api"); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; ; i++ {
+		elem, err := wd.FindElement(ById, "out")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if text, _ := elem.Text(); text == "mocked" {
+			break
+		}
+		if i == 20 {
+			t.Fatal("Mocked data not rendered")
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+}
+
+func TestShadowRoot(t *testing.T) {
+	t.Parallel()
+	wd := newRemote("TestShadowRoot", t)
+	defer wd.Quit()
+
+	if err := wd.Get(serverURL + "shadow"); err != nil {
+		t.Fatal(err)
+	}
+	host, err := wd.FindElement(ById, "host")
+	if err != nil {
+		t.Fatal(err)
+	}
+	root, err := host.ShadowRoot()
+	if err != nil {
+		t.Fatal(err)
+	}
+	elem, err := root.Q(".inner")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if text, err := elem.Text(); err != nil || text != "In the shadow." {
+		t.Errorf("Text returned %q, %v, want %q", text, err, "In the shadow.")
+	}
+}
+
+func TestSelectText(t *testing.T) {
+	t.Parallel()
+	wd := newRemote("TestSelectText", t)
+	defer wd.Quit()
+
+	if err := wd.Get(serverURL); err != nil {
+		t.Fatal(err)
+	}
+	elem, err := wd.FindElement(ById, "paragraph")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := elem.SelectText(); err != nil {
+		t.Fatal(err)
+	}
+	selected, err := wd.ExecuteScript("return window.getSelection().toString();", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "Select this paragraph."; selected != want {
+		t.Errorf("Selection is %q, want %q", selected, want)
+	}
+}
+
+func TestStructuredData(t *testing.T) {
+	t.Parallel()
+	wd := newRemote("TestStructuredData", t)
+	defer wd.Quit()
+
+	if err := wd.Get(serverURL + "structured"); err != nil {
+		t.Fatal(err)
+	}
+	data, err := wd.StructuredData()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(data) != 1 {
+		t.Fatalf("Got %d blocks, want 1", len(data))
+	}
+	var v struct {
+		Type string `json:"@type"`
+	}
+	if err := json.Unmarshal(data[0], &v); err != nil {
+		t.Fatal(err)
+	}
+	if v.Type != "Organization" {
+		t.Errorf("Got @type %q, want %q", v.Type, "Organization")
+	}
+}
+
+func TestTabTo(t *testing.T) {
+	t.Parallel()
+	wd := newRemote("TestTabTo", t)
+	defer wd.Quit()
+
+	if err := wd.Get(serverURL); err != nil {
+		t.Fatal(err)
+	}
+	target, err := wd.FindElement(ById, "chuk")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := wd.TabTo(target, 10); err != nil {
+		t.Fatal(err)
+	}
+	id, err := wd.ExecuteScript("return document.activeElement.id;", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id != "chuk" {
+		t.Errorf("Focused element is %q, want %q", id, "chuk")
+	}
+}
+
+func TestRenderedLines(t *testing.T) {
+	t.Parallel()
+	wd := newRemote("TestRenderedLines", t)
+	defer wd.Quit()
+
+	if err := wd.Get(serverURL); err != nil {
+		t.Fatal(err)
+	}
+	elem, err := wd.FindElement(ById, "address")
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines, err := elem.RenderedLines()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"1 Main St", "Springfield", "USA"}; !reflect.DeepEqual(lines, want) {
+		t.Errorf("RenderedLines returned %q, want %q", lines, want)
+	}
+}
+
+func TestVisibilityReason(t *testing.T) {
+	t.Parallel()
+	wd := newRemote("TestVisibilityReason", t).T(t)
+	defer wd.Quit()
+
+	wd.Get(serverURL + "visibility")
+	for id, want := range map[string]string{
+		"none":  "display",
+		"empty": "size",
+		"shown": "visible",
+	} {
+		reason, err := wd.FindElement(ById, id).WebElement().VisibilityReason()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(reason, want) {
+			t.Errorf("Reason for %q is %q, want it to mention %q", id, reason, want)
+		}
+	}
+}
+
+func TestInFrame(t *testing.T) {
+	t.Parallel()
+	wd := newRemote("TestInFrame", t)
+	defer wd.Quit()
+
+	if err := wd.Get(serverURL + "frames"); err != nil {
+		t.Fatal(err)
+	}
+	err := InFrame(wd, "frame", func() error {
+		body, err := wd.FindElement(ByTagName, "body")
+		if err != nil {
+			return err
+		}
+		text, err := body.Text()
+		if err != nil {
+			return err
+		}
+		if !strings.Contains(text, "The other page.") {
+			t.Errorf("Bad frame text %q", text)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	top, err := wd.ExecuteScript("return window.self === window.top", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if top != true {
+		t.Fatal("Not back at the top frame")
+	}
+}
+
 // Test server
 
 var homePage = `
@@ -568,7 +1302,7 @@ var homePage = `
 	<form action="/search">
 		<input name="q" /> <input type="submit" id="submit"/> <br />
 		<input id="chuk" type="checkbox" /> A checkbox.
-		<input type="hidden" name="hidden_name" />
+		<input type="hidden" name="hidden_name" data-flag="" />
 	</form>
     <ol class="list">
       <li>foo</li>
@@ -578,6 +1312,13 @@ var homePage = `
       <li>baz</li>
       <li>qux</li>
     </ol>
+    <select name="color">
+      <option value="r">Red</option>
+      <option value="g" selected>Green</option>
+      <option value="b">Blue</option>
+    </select>
+    <div id="address">1 Main St<br />Springfield<br /><br />USA</div>
+    <p id="paragraph">Select this paragraph.</p>
 </body>
 </html>
 `
@@ -607,10 +1348,190 @@ var searchPage = `
 </html>
 `
 
+var touchPage = `
+<html>
+<head>
+	<title>Go Selenium Test Suite - Touch Page</title>
+</head>
+<body>
+	<div id="zoom" style="width: 300px; height: 300px; touch-action: none"></div>
+	<script>
+	window.pinchScale = 1;
+	var start = 0;
+	function distance(touches) {
+		var dx = touches[0].clientX - touches[1].clientX;
+		var dy = touches[0].clientY - touches[1].clientY;
+		return Math.sqrt(dx * dx + dy * dy);
+	}
+	var zoom = document.getElementById("zoom");
+	zoom.addEventListener("touchstart", function(e) {
+		if (e.touches.length == 2) {
+			start = distance(e.touches);
+		}
+	});
+	zoom.addEventListener("touchmove", function(e) {
+		if (e.touches.length == 2 && start > 0) {
+			window.pinchScale = distance(e.touches) / start;
+		}
+	});
+	</script>
+</body>
+</html>
+`
+
+var apiPage = `
+<html>
+<head>
+	<title>Go Selenium Test Suite - API Page</title>
+</head>
+<body>
+	<div id="out"></div>
+	<script>
+	fetch("/api/data").then(function(res) { return res.json(); }).then(function(data) {
+		document.getElementById("out").textContent = data.name;
+	});
+	</script>
+</body>
+</html>
+`
+
+var imagesPage = `
+<html>
+<head>
+	<title>Go Selenium Test Suite - Images Page</title>
+</head>
+<body>
+	<img src="data:image/gif;base64,R0lGODlhAQABAIAAAAAAAP///yH5BAEAAAAALAAAAAABAAEAAAIBRAA7" />
+	<img src="/missing.png" />
+</body>
+</html>
+`
+
+var visibilityPage = `
+<html>
+<head>
+	<title>Go Selenium Test Suite - Visibility Page</title>
+</head>
+<body>
+	<div id="none" style="display: none">Not displayed.</div>
+	<div id="empty" style="width: 0; height: 0; overflow: hidden">Zero size.</div>
+	<div id="shown">Shown.</div>
+	<div id="below" style="margin-top: 3000px; width: 100px; height: 50px; background: red"></div>
+</body>
+</html>
+`
+
+var framesPage = `
+<html>
+<head>
+	<title>Go Selenium Test Suite - Frames Page</title>
+</head>
+<body>
+	The frames page.
+	<iframe id="frame" name="frame" src="/other"></iframe>
+</body>
+</html>
+`
+
+var structuredDataPage = `
+<html>
+<head>
+	<title>Go Selenium Test Suite - Structured Data Page</title>
+	<script type="application/ld+json">
+	{"@context": "https://schema.org", "@type": "Organization", "name": "Go Selenium"}
+	</script>
+</head>
+<body>
+	The structured data page.
+</body>
+</html>
+`
+
+var dragPage = `
+<html>
+<head>
+	<title>Go Selenium Test Suite - Drag Page</title>
+</head>
+<body>
+	<div id="item" style="width: 50px; height: 50px; background: blue">Drag me</div>
+	<iframe id="frame" src="/dropzone" style="margin: 40px"></iframe>
+	<script>
+	var dragging = false;
+	document.getElementById("item").addEventListener("mousedown", function() {
+		dragging = true;
+	});
+	</script>
+</body>
+</html>
+`
+
+var dropzonePage = `
+<html>
+<head>
+	<title>Go Selenium Test Suite - Dropzone Page</title>
+</head>
+<body>
+	<div id="dropzone" style="width: 100px; height: 100px; margin: 20px; background: green">Drop here</div>
+	<script>
+	// Only a drop which reaches the frame lands here.
+	document.getElementById("dropzone").addEventListener("mouseup", function() {
+		if (parent.dragging) {
+			parent.dragging = false;
+			this.textContent = "Dropped";
+		}
+	});
+	</script>
+</body>
+</html>
+`
+
+var shadowPage = `
+<html>
+<head>
+	<title>Go Selenium Test Suite - Shadow Page</title>
+</head>
+<body>
+	<div id="host"></div>
+	<script>
+	var root = document.getElementById("host").attachShadow({mode: "open"});
+	root.innerHTML = '<span class="inner">In the shadow.</span>';
+	</script>
+</body>
+</html>
+`
+
+var spinnerPage = `
+<html>
+<head>
+	<title>Go Selenium Test Suite - Spinner Page</title>
+</head>
+<body>
+	<div id="spinner">Loading...</div>
+	<script>
+	setTimeout(function() {
+		var spinner = document.getElementById("spinner");
+		spinner.parentNode.removeChild(spinner);
+	}, 500);
+	</script>
+</body>
+</html>
+`
+
 var pages = map[string]string{
-	"/":       homePage,
-	"/other":  otherPage,
-	"/search": searchPage,
+	"/":           homePage,
+	"/other":      otherPage,
+	"/search":     searchPage,
+	"/touch":      touchPage,
+	"/api":        apiPage,
+	"/images":     imagesPage,
+	"/visibility": visibilityPage,
+	"/frames":     framesPage,
+	"/structured": structuredDataPage,
+	"/drag":       dragPage,
+	"/dropzone":   dropzonePage,
+	"/httponly":   otherPage,
+	"/shadow":     shadowPage,
+	"/spinner":    spinnerPage,
 }
 
 var cookieExpiry = time.Now().Add(1 * time.Hour).UTC()
@@ -633,6 +1554,9 @@ func handler(w http.ResponseWriter, r *http.Request) {
 		value := fmt.Sprintf("value-%d", i)
 		http.SetCookie(w, &http.Cookie{Name: name, Value: value, Expires: cookieExpiry})
 	}
+	if path == "/httponly" {
+		http.SetCookie(w, &http.Cookie{Name: "private", Value: "secret", HttpOnly: true})
+	}
 
 	fmt.Fprintf(w, page)
 }
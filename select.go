@@ -0,0 +1,46 @@
+package selenium
+
+import (
+	"encoding/json"
+	"errors"
+)
+
+// Select wraps a <select> element.
+type Select struct {
+	elem WebElement
+}
+
+// NewSelect returns a Select for elem, which must be a <select> element.
+func NewSelect(elem WebElement) *Select {
+	return &Select{elem: elem}
+}
+
+// Option is an <option> of a Select.
+type Option struct {
+	Text     string `json:"text"`
+	Value    string `json:"value"`
+	Selected bool   `json:"selected"`
+}
+
+// Options returns all options of the select, in document order, using a
+// single script call.
+func (s *Select) Options() ([]Option, error) {
+	elem, ok := s.elem.(*remoteWE)
+	if !ok {
+		return nil, errors.New("select: not a remote element")
+	}
+	script := `return Array.prototype.map.call(arguments[0].options, function(o) {
+		return {text: o.text, value: o.value, selected: o.selected};
+	});`
+	res, err := elem.parent.ExecuteScript(script, []interface{}{elem})
+	if err != nil {
+		return nil, err
+	}
+	data, err := json.Marshal(res)
+	if err != nil {
+		return nil, err
+	}
+	var options []Option
+	err = json.Unmarshal(data, &options)
+	return options, err
+}
@@ -2,7 +2,11 @@ package selenium
 
 import (
 	"context"
+	"encoding/json"
+	"image/color"
 	"io"
+	"net/http"
+	"time"
 )
 
 /* Element finding options */
@@ -24,6 +28,13 @@ const (
 	RightButton
 )
 
+/* Network connection types, combine them for SetNetworkConnection */
+const (
+	AirplaneMode   = 1
+	WifiConnection = 2
+	DataConnection = 4
+)
+
 /* Keys */
 const (
 	NullKey       = string('\ue000')
@@ -100,10 +111,47 @@ type OS struct {
 
 /* Information retured by Status method. */
 type Status struct {
+	// Ready and Message are reported by W3C servers.
+	Ready   bool   `json:"ready"`
+	Message string `json:"message"`
+	// Build and OS are reported by JSON Wire servers.
 	Build `json:"build"`
 	OS    `json:"os"`
 }
 
+/* Session timeouts in milliseconds, see SetTimeouts. */
+type Timeouts struct {
+	Implicit uint `json:"implicit,omitempty"`
+	PageLoad uint `json:"pageLoad,omitempty"`
+	Script   uint `json:"script,omitempty"`
+}
+
+/* Options for PrintPage, zero fields use the driver's defaults. Lengths are in centimeters. */
+type PrintOptions struct {
+	// Orientation is "portrait" or "landscape".
+	Orientation string   `json:"orientation,omitempty"`
+	Scale       float64  `json:"scale,omitempty"`
+	Background  bool     `json:"background,omitempty"`
+	PageRanges  []string `json:"pageRanges,omitempty"`
+	PageWidth   float64  `json:"-"`
+	PageHeight  float64  `json:"-"`
+	// Margin replaces the default margins when set, zero included.
+	Margin *PrintMargin `json:"margin,omitempty"`
+}
+
+/* Page margins for PrintOptions, in centimeters. */
+type PrintMargin struct {
+	Top    float64 `json:"top"`
+	Bottom float64 `json:"bottom"`
+	Left   float64 `json:"left"`
+	Right  float64 `json:"right"`
+}
+
+/* Geographical location, see SetLocation. */
+type Location struct {
+	Latitude, Longitude, Altitude float64
+}
+
 /* Point */
 type Point struct {
 	X, Y float64
@@ -115,18 +163,60 @@ type Size struct {
 	Height float64 `json:"height"`
 }
 
+/* Rect */
+type Rect struct {
+	X      float64 `json:"x"`
+	Y      float64 `json:"y"`
+	Width  float64 `json:"width"`
+	Height float64 `json:"height"`
+}
+
 /* Cookie */
 type Cookie struct {
-	Name   string `json:"name"`
-	Value  string `json:"value"`
-	Path   string `json:"path"`
-	Domain string `json:"domain"`
-	Secure bool   `json:"secure"`
-	Expiry uint   `json:"-"`
+	Name     string `json:"name"`
+	Value    string `json:"value"`
+	Path     string `json:"path"`
+	Domain   string `json:"domain"`
+	Secure   bool   `json:"secure"`
+	HttpOnly bool   `json:"httpOnly"`
+	// SameSite is "Strict", "Lax" or "None", empty for the browser default.
+	SameSite string `json:"sameSite,omitempty"`
+	// Expires is when the cookie expires, the zero time for a session cookie.
+	// It takes precedence over Expiry when adding a cookie.
+	Expires time.Time `json:"-"`
+	// Expiry is the expiry in unix seconds, kept for compatibility. Use
+	// Expires and ExpiresAt instead.
+	Expiry uint `json:"-"`
+}
+
+// ExpiresAt returns when the cookie expires, or the zero time for a session
+// cookie.
+func (c *Cookie) ExpiresAt() time.Time {
+	if !c.Expires.IsZero() {
+		return c.Expires
+	}
+	if c.Expiry == 0 {
+		return time.Time{}
+	}
+	return time.Unix(int64(c.Expiry), 0)
 }
 
+// WebDriver controls a browser session. Its methods may be called from
+// several goroutines at once, except NewSession and Quit which replace the
+// session and must not overlap other commands.
 type WebDriver interface {
+	/* Set the context bounding the driver's lifetime. Once it is done, the next command returns
+	   ErrCanceled and quits the session. */
 	SetContext(context.Context)
+	/* Set a context for subsequent commands. Once it is done, commands return ErrCanceled but the
+	   session stays alive; set a new context (or nil) to continue using the driver. */
+	SetCommandContext(context.Context)
+	/* Return a driver for the same session whose commands run under context.Background(), for
+	   scripts that don't want to handle contexts. The contexts set on this driver with
+	   SetContext and SetCommandContext don't bound or cancel its commands. Like a driver
+	   from AttachToSession, it starts in the current frame but doesn't follow the windows
+	   and frames this driver switches to afterwards, and quitting it quits the session. */
+	Simple() WebDriver
 
 	/* Status (info) on server */
 	Status() (*Status, error)
@@ -142,10 +232,29 @@ type WebDriver interface {
 
 	/* Current session capabilities */
 	Capabilities() (Capabilities, error)
+	/* Negotiated session capabilities, with the common ones as typed fields.
+	   ErrNotSupported for a W3C session attached without AttachCapabilities. */
+	SessionCapabilities() (*SessionInfo, error)
+	/* The Selenium Grid node running the session, ErrNotSupported without a Grid, see WithGridURL */
+	SessionNodeInfo() (*NodeInfo, error)
+
+	// Feature support, derived from the negotiated browser and protocol. Use
+	// these to skip driver-specific features instead of handling
+	// "unknown command" errors.
+	/* Whether Chrome DevTools Protocol commands are available. */
+	SupportsCDP() bool
+	/* Whether the page can be printed to PDF. */
+	SupportsPrint() bool
+	/* Whether W3C input actions are available. */
+	SupportsActions() bool
 
 	/* Configure the amount of time a particular type of operation can execute for before it is aborted.
-	   Valid types: "script" for script timeouts, "implicit" for modifying the implicit wait timeout and "page load" for setting a page load timeout. */
+	   Valid types: "script" for script timeouts, "implicit" for modifying the implicit wait timeout and "page load" for setting a page load timeout.
+	   These are enforced by the server, the client gives up on a command after the WithCommandTimeout duration. */
 	SetTimeout(timeoutType string, ms uint) error
+	/* Set the implicit, page load and script timeouts at once (W3C). Zero fields are left unchanged,
+	   use SetTimeout to set a single timeout to zero. */
+	SetTimeouts(timeouts Timeouts) error
 	/* Set the amount of time, in milliseconds, that asynchronous scripts are permitted to run before they are aborted. */
 	SetAsyncScriptTimeout(ms uint) error
 	/* Set the amount of time, in milliseconds, the driver should wait when searching for elements. */
@@ -163,27 +272,61 @@ type WebDriver interface {
 	/* Make an engines active */
 	ActivateEngine(engine string) error
 
-	/* Quit (end) current session */
+	/* Quit (end) current session. Quitting a session again is a no-op, but a
+	   session started afterwards with NewSession or Restart can be quit. */
 	Quit() error
+	/* Same as Quit */
+	DeleteSession() error
+	/* Quit the current session, ignoring errors, and start a new one with the same capabilities.
+	   The driver keeps working with the new session; contexts set with SetContext and
+	   SetCommandContext are cleared. */
+	Restart() error
 
 	// Page information and manipulation
 	/* Return id of current window handle. */
 	CurrentWindowHandle() (string, error)
 	/* Return ids of current open windows. */
 	WindowHandles() ([]string, error)
+	/* Open a new "tab" (the default) or "window" and return its handle, without switching to it. */
+	NewWindow(typ string) (string, error)
+	/* Open a new tab and return a driver for it. The driver shares the session, and each
+	   driver of the session, this one included, keeps its own window and frames, switching
+	   back to them before a command if another driver switched away. The commands of the
+	   drivers then run one at a time. The tab driver's Quit closes only the tab. */
+	NewTab() (WebDriver, error)
+	/* Number of open tabs and windows. */
+	TabCount() (int, error)
+	/* Switch to the tab at index in WindowHandles. The order is the server's and
+	   may not match the visual order of the tabs. */
+	SwitchToTab(index int) error
+	/* Close the tab at index in WindowHandles. If it was the current tab, switch
+	   to another one before sending further commands. */
+	CloseTab(index int) error
 	/* Current url. */
 	CurrentURL() (string, error)
 	/* Page title. */
 	Title() (string, error)
 	/* Get page source. */
 	PageSource() (string, error)
+	/* Override the browser's geolocation. On Chrome, it first grants the geolocation permission
+	   to the current page's origin so that no prompt blocks it, unless the origin is opaque, e.g.
+	   of about:blank, or GrantLocationPermission(false) is passed. */
+	SetLocation(loc Location, opts ...LocationOption) error
+	/* Get the JSON-LD blocks of the page. */
+	StructuredData() ([]json.RawMessage, error)
 	/* Close current window. */
 	Close() error
-	/* Switch to frame, frame parameter can be name or id. */
-	SwitchFrame(frame string) error
+	/* Switch to frame, frame parameter can be name or id, index, element or nil for the top-level document. */
+	SwitchFrame(frame interface{}) error
+	/* Switch to the top-level document */
+	SwitchToDefaultContent() error
 	/* Switch to parent frame */
 	SwitchFrameParent() error
-	/* Swtich to window. */
+	/* Get the frames switched into from the top-level document, outermost first, each as
+	   its name or id, index or element id; empty in the top-level document. It only reflects
+	   the switches made through this driver, and navigating or switching windows resets it. */
+	CurrentFramePath() []string
+	/* Swtich to window. W3C drivers only accept a handle from WindowHandles. */
 	SwitchWindow(name string) error
 	/* Close window. */
 	CloseWindow(name string) error
@@ -191,6 +334,8 @@ type WebDriver interface {
 	WindowSize(name string) (*Size, error)
 	/* Get window position */
 	WindowPosition(name string) (*Point, error)
+	/* Get the position and size of the current window */
+	CurrentWindowRect() (*Rect, error)
 
 	// ResizeWindow resizes the named window.
 	ResizeWindow(name string, to Size) error
@@ -208,23 +353,52 @@ type WebDriver interface {
 	// Finding element(s)
 	/* Find, return one element. */
 	FindElement(by, value string) (WebElement, error)
-	/* Find, return list of elements. */
+	/* Find, return list of elements, empty if none match. */
 	FindElements(by, value string) ([]WebElement, error)
+	/* Number of matching elements. */
+	CountElements(by, value string) (int, error)
+	/* Whether an element matches, without a "no such element" error. */
+	IsElementPresent(by, value string) (bool, error)
+	/* Poll every interval until an element matches, return ErrWaitTimeout after timeout. */
+	WaitForElement(by, value string, timeout, interval time.Duration) (WebElement, error)
+	/* Like WaitForElement, but the element must also be displayed. */
+	WaitForVisibleElement(by, value string, timeout, interval time.Duration) (WebElement, error)
+	/* Poll every interval until no element matches or it is hidden, return ErrWaitTimeout after timeout. */
+	WaitForElementGone(by, value string, timeout, interval time.Duration) error
 	/* Current active element. */
 	ActiveElement() (WebElement, error)
+	/* Submit the form containing the active element. */
+	SubmitActiveElement() error
+	/* Press tab until target has focus, failing after maxTabs presses. */
+	TabTo(target WebElement, maxTabs int) error
 
 	// Shortcut for FindElement(ByCSSSelector, sel)
 	Q(sel string) (WebElement, error)
 	// Shortcut for FindElements(ByCSSSelector, sel)
 	QAll(sel string) ([]WebElement, error)
+	/* Bounding client rects of all elements matching the CSS selector, in one call. */
+	RectsOf(sel string) ([]Rect, error)
+	/* Sources of all images on the page that finished loading without image data. */
+	FindBrokenImages() ([]string, error)
 
 	// Cookies
 	/* Get all cookies */
 	GetCookies() ([]Cookie, error)
+	/* Get the cookies of all domains, HttpOnly ones included. Only supported on Chrome. */
+	GetAllCookiesCDP() ([]Cookie, error)
+	/* Get the named cookie, return ErrNoSuchCookie if it isn't set */
+	GetNamedCookie(name string) (*Cookie, error)
 	/* Add a cookie */
 	AddCookie(cookie *Cookie) error
+	/* Add a cookie from the standard library, e.g. one set by a login API response */
+	AddHTTPCookie(cookie *http.Cookie) error
 	/* Delete all cookies */
 	DeleteAllCookies() error
+	/* Serialize all cookies to JSON, for ImportCookies */
+	ExportCookies() ([]byte, error)
+	/* Add the cookies serialized by ExportCookies. The current page must be on
+	   their domain. */
+	ImportCookies(data []byte) error
 	/* Delete a cookie */
 	DeleteCookie(name string) error
 
@@ -240,12 +414,53 @@ type WebDriver interface {
 	/* Mouse button up */
 	ButtonUp() error
 
+	// Touch, for mobile (Appium-compatible) servers
+	/* Single tap on element */
+	Tap(elem WebElement) error
+	/* Double tap on element */
+	DoubleTap(elem WebElement) error
+	/* Long press on element */
+	LongPress(elem WebElement) error
+	/* Finger down at the viewport coordinates */
+	TouchDown(x, y int) error
+	/* Finger up at the viewport coordinates */
+	TouchUp(x, y int) error
+	/* Move the finger to the viewport coordinates */
+	TouchMove(x, y int) error
+	/* Scroll by the offsets, starting on elem, or anywhere if elem is nil */
+	TouchScroll(elem WebElement, xOffset, yOffset int) error
+	/* Flick from elem, which is required, by the offsets, at speed pixels per second */
+	TouchFlick(elem WebElement, xOffset, yOffset, speed int) error
+
+	// Actions
+	/* Start building a sequence of W3C input actions. */
+	Actions() *Actions
+	/* Release all keys and pointer buttons held down by performed actions. */
+	ReleaseActions() error
+
+	// Mobile
+	/* Set the device's network connection, a combination of AirplaneMode, WifiConnection and
+	   DataConnection. Only supported by mobile (Appium-compatible) servers. */
+	SetNetworkConnection(bitmask int) error
+	/* Get the device's network connection bitmask. */
+	NetworkConnection() (int, error)
+
 	// Misc
 	/* Send modifier key to active element.
 	modifier can be one of ShiftKey, ControlKey, AltKey, MetaKey.
 	*/
 	SendModifier(modifier string, isDown bool) error
+	/* Press a shortcut on the active element, e.g. SendKeyChord(ControlKey, "a"):
+	   the keys are pressed in order and released in reverse. */
+	SendKeyChord(keys ...string) error
 	Screenshot() (io.Reader, error)
+	/* Print the page to PDF (W3C only). */
+	PrintPage(opts PrintOptions) (io.Reader, error)
+	/* Print the page to a PDF file at path (W3C only). */
+	PrintPageToFile(path string, opts PrintOptions) error
+	/* Upload a local file to the server, return the file's path on the remote
+	   machine. Use it to fill file inputs when running against a remote Grid. */
+	UploadFile(path string) (string, error)
 
 	// Alerts
 	/* Dismiss current alert. */
@@ -256,12 +471,42 @@ type WebDriver interface {
 	AlertText() (string, error)
 	/* Set current alert text. */
 	SetAlertText(text string) error
+	/* Wait for an alert to open, accept it and return its text. Returns ErrWaitTimeout
+	   if no alert opens within timeout. */
+	WaitAlertAndAccept(timeout time.Duration) (string, error)
+	/* Like WaitAlertAndAccept, but dismiss the alert. */
+	WaitAlertAndDismiss(timeout time.Duration) (string, error)
+	/* Answer an HTTP authentication dialog. Returns ErrNotSupported if the driver
+	   cannot, then put the credentials in the URL instead. */
+	SendAlertCredentials(user, password string) error
 
 	// Scripts
 	/* Execute a script. */
 	ExecuteScript(script string, args []interface{}) (interface{}, error)
 	/* Execute a script async. */
 	ExecuteScriptAsync(script string, args []interface{}) (interface{}, error)
+	/* Execute a script and unmarshal its result into dst, as json.Unmarshal does. */
+	ExecuteScriptInto(script string, args []interface{}, dst interface{}) error
+
+	// Chrome DevTools
+	/* Run a Chrome DevTools Protocol command, e.g. "Network.clearBrowserCache",
+	   and return its result. Only supported on Chrome. */
+	ExecuteCDPCommand(cmd string, params map[string]interface{}) (interface{}, error)
+	/* Emulate network conditions. Only supported on Chrome. */
+	SetNetworkConditions(conditions NetworkConditions) error
+	/* Get the emulated network conditions. Only supported on Chrome. */
+	GetNetworkConditions() (*NetworkConditions, error)
+	/* Browser performance metrics (JSHeapUsedSize, Nodes, ...), keyed by name.
+	   Only supported on Chrome. */
+	PerformanceMetrics() (map[string]float64, error)
+	/* Capture the page as a single-file MHTML archive. Only supported on Chrome. */
+	CaptureSnapshotMHTML() (string, error)
+	/* Save the page as an MHTML archive at path. Only supported on Chrome. */
+	SaveMHTML(path string) error
+	/* Intercept requests whose URL matches pattern (wildcards * and ? allowed) and answer them with
+	   handler, until stop is called. Only supported on Chrome, when the browser's debugger address is
+	   reachable from this process (e.g. a local chromedriver). */
+	InterceptRequests(pattern string, handler func(InterceptedRequest) InterceptResponse) (stop func(), err error)
 
 	// Get a WebDriverT of this element that has methods that call t.Fatalf upon
 	// encountering errors instead of using multiple returns to indicate errors.
@@ -272,6 +517,9 @@ type WebDriver interface {
 
 	// Raw execution
 	VoidExecute(url string, params interface{}) error
+	/* Send a command, e.g. Execute("GET", "/session/%s/window/rect", nil), and return
+	   the value of its reply. A %s in path is replaced by the session id. */
+	Execute(method, path string, params interface{}) (json.RawMessage, error)
 }
 
 type WebElement interface {
@@ -279,12 +527,28 @@ type WebElement interface {
 
 	/* Click on element */
 	Click() error
+	/* Scroll element into view, wait for it to be displayed and enabled, then
+	   click, clicking again once if another element received the click. */
+	SafeClick() error
+	/* Click with the script element.click(), for elements that refuse native clicks.
+	   It skips the real mouse events and hit testing, so use it only as a fallback. */
+	JSClick() error
+	/* Double click on the center of element */
+	DoubleClick() error
+	/* Right click on the center of element, opening its context menu */
+	ContextClick() error
 	/* Send keys (type) into element */
 	SendKeys(keys string) error
+	/* Upload a local file and type its remote path into a file input */
+	UploadFile(localPath string) error
 	/* Submit */
 	Submit() error
 	/* Clear */
 	Clear() error
+	/* Clear, then send keys (type) into element */
+	ClearAndSendKeys(keys string) error
+	/* Press a shortcut in element, e.g. SendKeyChord(ControlKey, "a") */
+	SendKeyChord(keys ...string) error
 	/* Move mouse to relative coordinates */
 	MoveTo(xOffset, yOffset int) error
 
@@ -294,11 +558,15 @@ type WebElement interface {
 	FindElement(by, value string) (WebElement, error)
 	/* Find children, return list of elements. */
 	FindElements(by, value string) ([]WebElement, error)
+	/* Whether a child matches, without a "no such element" error. */
+	IsElementPresent(by, value string) (bool, error)
 
 	// Shortcut for FindElement(ByCSSSelector, sel)
 	Q(sel string) (WebElement, error)
 	// Shortcut for FindElements(ByCSSSelector, sel)
 	QAll(sel string) ([]WebElement, error)
+	/* The element's open shadow root (W3C only). */
+	ShadowRoot() (ShadowRoot, error)
 
 	// Porperties
 
@@ -306,14 +574,33 @@ type WebElement interface {
 	TagName() (string, error)
 	/* Text of element */
 	Text() (string, error)
+	/* Text of element, trimmed and with runs of whitespace collapsed to single spaces. */
+	NormalizedText() (string, error)
+	/* Rendered text of element split into lines, without blank lines. Lines are not trimmed. */
+	RenderedLines() ([]string, error)
 	/* Check if element is selected. */
 	IsSelected() (bool, error)
 	/* Check if element is enabled. */
 	IsEnabled() (bool, error)
 	/* Check if element is displayed. */
 	IsDisplayed() (bool, error)
+	/* Why the element is not visible (e.g. "display: none", "zero size"), or "visible". */
+	VisibilityReason() (string, error)
 	/* Get element attribute. */
 	GetAttribute(name string) (string, error)
+	/* Get element DOM property, e.g. "value" or "checked". */
+	GetProperty(name string) (interface{}, error)
+	/* Markup of the element's children. */
+	InnerHTML() (string, error)
+	/* Markup of the element, itself included. */
+	OuterHTML() (string, error)
+	/* Check if element has the attribute, even if its value is empty. */
+	HasAttribute(name string) (bool, error)
+	/* All attributes of the element by name. */
+	Attributes() (map[string]string, error)
+	/* The values of the named attributes, read with a single script. Attributes the element
+	   doesn't have are left out of the map. */
+	GetAttributes(names ...string) (map[string]string, error)
 	/* Element location. */
 	Location() (*Point, error)
 	/* Element location once it has been scrolled into view.
@@ -321,8 +608,21 @@ type WebElement interface {
 	LocationInView() (*Point, error)
 	/* Element size */
 	Size() (*Size, error)
+	/* Element location and size */
+	Rect() (*Rect, error)
 	/* Get element CSS property value. */
 	CSSProperty(name string) (string, error)
+	/* Get a CSS color property, e.g. "background-color". The color is
+	   alpha-premultiplied, as color.RGBA always is. */
+	CSSColor(name string) (color.RGBA, error)
+	/* Get a CSS length property in pixels, e.g. "font-size". */
+	CSSPixels(name string) (float64, error)
+	/* Select all text within the element. */
+	SelectText() error
+	/* Scroll the element into view if it is outside the viewport. */
+	ScrollIntoView() error
+	/* Take a screenshot of the element, scrolling it into view first if scroll is true. */
+	Screenshot(scroll bool) (io.Reader, error)
 
 	// Get a WebElementT of this element that has methods that call t.Fatalf
 	// upon encountering errors instead of using multiple returns to indicate
@@ -332,6 +632,20 @@ type WebElement interface {
 	T(t TestingT) WebElementT
 }
 
+// ShadowRoot is the shadow root of a web component, see WebElement.ShadowRoot.
+// Only CSS selectors are guaranteed to work within it.
+type ShadowRoot interface {
+	/* Find, return one element in the shadow tree. */
+	FindElement(by, value string) (WebElement, error)
+	/* Find, return list of elements in the shadow tree. */
+	FindElements(by, value string) ([]WebElement, error)
+
+	// Shortcut for FindElement(ByCSSSelector, sel)
+	Q(sel string) (WebElement, error)
+	// Shortcut for FindElements(ByCSSSelector, sel)
+	QAll(sel string) ([]WebElement, error)
+}
+
 // TestingT is a subset of the testing.T interface (to avoid needing
 // to import "testing", which registers global command-line flags).
 type TestingT interface {
@@ -22,12 +22,21 @@ var (
 // configured to talk to that test server.  Tests should register handlers on
 // mux which provide mock responses for the API method being tested.
 func setup() {
+	setupSession(`{"sessionId": "123"}`)
+}
+
+// setupW3C is like setup, but the test server negotiates the W3C protocol.
+func setupW3C() {
+	setupSession(`{"value": {"sessionId": "123", "capabilities": {}}}`)
+}
+
+func setupSession(newSessionReply string) {
 	// test server
 	mux = http.NewServeMux()
 	server = httptest.NewServer(mux)
 
 	mux.HandleFunc("/session", func(w http.ResponseWriter, r *http.Request) {
-		fmt.Fprintf(w, `{"sessionId": "123"}`)
+		fmt.Fprint(w, newSessionReply)
 	})
 
 	// selenium client configured to use test server
@@ -0,0 +1,200 @@
+package selenium
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"strconv"
+	"time"
+)
+
+// DriverService is a locally running WebDriver binary, such as chromedriver
+// or geckodriver. Pass its URL to NewRemote and call Stop when done.
+type DriverService struct {
+	cmd *exec.Cmd
+	// exited is closed once the process has exited, with waitErr set to
+	// its exit status.
+	exited       chan struct{}
+	waitErr      error
+	port         int
+	args         []string
+	output       io.Writer
+	startTimeout time.Duration
+}
+
+// ServiceOption configures a DriverService.
+type ServiceOption func(*DriverService)
+
+// ServiceArgs passes additional command-line arguments to the driver binary.
+func ServiceArgs(args ...string) ServiceOption {
+	return func(s *DriverService) {
+		s.args = append(s.args, args...)
+	}
+}
+
+// ServiceOutput sends the driver's stdout and stderr to w.
+func ServiceOutput(w io.Writer) ServiceOption {
+	return func(s *DriverService) {
+		s.output = w
+	}
+}
+
+// ServiceStartTimeout sets how long to wait for the driver to become ready
+// (default 20 seconds).
+func ServiceStartTimeout(d time.Duration) ServiceOption {
+	return func(s *DriverService) {
+		s.startTimeout = d
+	}
+}
+
+// NewChromeDriverService starts the chromedriver binary at path listening on
+// port (0 picks a free port) and waits until it is ready.
+func NewChromeDriverService(path string, port int, opts ...ServiceOption) (*DriverService, error) {
+	return newDriverService(path, port, func(port int) []string {
+		return []string{"--port=" + strconv.Itoa(port)}
+	}, opts)
+}
+
+// NewGeckoDriverService starts the geckodriver binary at path listening on
+// port (0 picks a free port) and waits until it is ready.
+func NewGeckoDriverService(path string, port int, opts ...ServiceOption) (*DriverService, error) {
+	return newDriverService(path, port, func(port int) []string {
+		return []string{"--port", strconv.Itoa(port)}
+	}, opts)
+}
+
+func newDriverService(path string, port int, portArgs func(int) []string, opts []ServiceOption) (*DriverService, error) {
+	if port == 0 {
+		var err error
+		if port, err = freePort(); err != nil {
+			return nil, err
+		}
+	}
+	s := &DriverService{port: port, startTimeout: 20 * time.Second}
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	s.cmd = exec.Command(path, append(portArgs(port), s.args...)...)
+	s.cmd.Stdout = s.output
+	s.cmd.Stderr = s.output
+	if err := s.cmd.Start(); err != nil {
+		return nil, err
+	}
+	s.exited = make(chan struct{})
+	go func() {
+		s.waitErr = s.cmd.Wait()
+		close(s.exited)
+	}()
+	if err := waitForStatus(s.URL()+"/status", s.startTimeout, s.exited); err != nil {
+		select {
+		case <-s.exited:
+			return nil, fmt.Errorf("%s exited before becoming ready: %v", path, s.waitErr)
+		default:
+		}
+		s.Stop()
+		return nil, fmt.Errorf("%s did not become ready: %s", path, err)
+	}
+	return s, nil
+}
+
+// URL is the executor URL of the service.
+func (s *DriverService) URL() string {
+	return "http://127.0.0.1:" + strconv.Itoa(s.port)
+}
+
+// Stop kills the driver process.
+func (s *DriverService) Stop() error {
+	select {
+	case <-s.exited:
+		return nil
+	default:
+	}
+	if err := s.cmd.Process.Kill(); err != nil && !errors.Is(err, os.ErrProcessDone) {
+		return err
+	}
+	// The exit status reports the kill signal, there is nothing to act on.
+	<-s.exited
+	return nil
+}
+
+func freePort() (int, error) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return 0, err
+	}
+	defer l.Close()
+	return l.Addr().(*net.TCPAddr).Port, nil
+}
+
+// WaitForServer polls the executor's status endpoint until the server is
+// ready or timeout elapses. Call it before NewRemote when the Selenium server
+// is started concurrently with the tests.
+func WaitForServer(executor string, timeout time.Duration) error {
+	if executor == "" {
+		executor = defaultExecutor
+	}
+	return waitForStatus(executor+"/status", timeout, nil)
+}
+
+// waitForStatus polls url until it answers 200 without reporting that the
+// server is not ready, timeout elapses or stop is closed. A request still
+// waiting for its reply is abandoned then.
+func waitForStatus(url string, timeout time.Duration, stop <-chan struct{}) error {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	go func() {
+		select {
+		case <-stop:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	for {
+		err := checkStatus(ctx, url)
+		if err == nil {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+}
+
+func checkStatus(ctx context.Context, url string) error {
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return err
+	}
+	res, err := http.DefaultClient.Do(req.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("status %s", res.Status)
+	}
+	body, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return err
+	}
+	// W3C servers report readiness, JSON Wire servers answering at all are ready.
+	var status struct {
+		Value struct {
+			Ready *bool `json:"ready"`
+		} `json:"value"`
+	}
+	if json.Unmarshal(body, &status) == nil && status.Value.Ready != nil && !*status.Value.Ready {
+		return fmt.Errorf("server not ready")
+	}
+	return nil
+}
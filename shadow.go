@@ -0,0 +1,43 @@
+package selenium
+
+import (
+	"fmt"
+)
+
+// shadowRootKey identifies a shadow root reference object in the W3C
+// protocol.
+const shadowRootKey = "shadow-6066-11e4-a52e-4f735466cecf"
+
+type remoteShadowRoot struct {
+	parent *remoteWebDriver
+	id     string
+}
+
+func (elem *remoteWE) ShadowRoot() (ShadowRoot, error) {
+	wd := elem.parent
+	r, err := wd.send("ShadowRoot", "GET", wd.url("/session/%s/element/%s/shadow", wd.sessionID(), elem.id), nil)
+	if err != nil {
+		return nil, err
+	}
+	var ref map[string]string
+	if err := r.readValue(&ref); err != nil || ref[shadowRootKey] == "" {
+		return nil, fmt.Errorf("bad shadow root reply %s", r.Value)
+	}
+	return &remoteShadowRoot{parent: wd, id: ref[shadowRootKey]}, nil
+}
+
+func (root *remoteShadowRoot) FindElement(by, value string) (WebElement, error) {
+	return root.parent.findOne("FindElement", fmt.Sprintf("/session/%%s/shadow/%s/element", root.id), by, value)
+}
+
+func (root *remoteShadowRoot) FindElements(by, value string) ([]WebElement, error) {
+	return root.parent.findMany("FindElements", fmt.Sprintf("/session/%%s/shadow/%s/elements", root.id), by, value)
+}
+
+func (root *remoteShadowRoot) Q(sel string) (WebElement, error) {
+	return root.FindElement(ByCSSSelector, sel)
+}
+
+func (root *remoteShadowRoot) QAll(sel string) ([]WebElement, error) {
+	return root.FindElements(ByCSSSelector, sel)
+}
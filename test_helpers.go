@@ -2,6 +2,8 @@ package selenium
 
 import (
 	"fmt"
+	"image"
+	"image/png"
 	"io"
 	"path/filepath"
 	"runtime"
@@ -19,6 +21,7 @@ type WebDriverT interface {
 	NewSession() string
 
 	SetTimeout(timeoutType string, ms uint)
+	SetTimeouts(timeouts Timeouts)
 	SetAsyncScriptTimeout(ms uint)
 	SetImplicitWaitTimeout(ms uint)
 
@@ -30,7 +33,8 @@ type WebDriverT interface {
 	Title() string
 	PageSource() string
 	Close()
-	SwitchFrame(frame string)
+	SwitchFrame(frame interface{})
+	SwitchToDefaultContent()
 	SwitchFrameParent()
 	SwitchWindow(name string)
 	CloseWindow(name string)
@@ -45,7 +49,7 @@ type WebDriverT interface {
 
 	FindElement(by, value string) WebElementT
 	FindElements(by, value string) []WebElementT
-	ActiveElement() WebElement
+	ActiveElement() WebElementT
 
 	// Shortcut for FindElement(ByCSSSelector, sel)
 	Q(sel string) WebElementT
@@ -72,6 +76,9 @@ type WebDriverT interface {
 
 	ExecuteScript(script string, args []interface{}) interface{}
 	ExecuteScriptAsync(script string, args []interface{}) interface{}
+
+	// Fails the test if the page has broken images.
+	AssertNoBrokenImages()
 }
 
 type webDriverT struct {
@@ -97,6 +104,12 @@ func (wt *webDriverT) SetTimeout(timeoutType string, ms uint) {
 	}
 }
 
+func (wt *webDriverT) SetTimeouts(timeouts Timeouts) {
+	if err := wt.d.SetTimeouts(timeouts); err != nil {
+		fatalf(wt.t, "SetTimeouts(%+v): %s", timeouts, err)
+	}
+}
+
 func (wt *webDriverT) SetAsyncScriptTimeout(ms uint) {
 	if err := wt.d.SetAsyncScriptTimeout(ms); err != nil {
 		fatalf(wt.t, "SetAsyncScriptTimeout(%d msec): %s", ms, err)
@@ -161,9 +174,15 @@ func (wt *webDriverT) Close() {
 	}
 }
 
-func (wt *webDriverT) SwitchFrame(frame string) {
+func (wt *webDriverT) SwitchFrame(frame interface{}) {
 	if err := wt.d.SwitchFrame(frame); err != nil {
-		fatalf(wt.t, "SwitchFrame(%q): %s", frame, err)
+		fatalf(wt.t, "SwitchFrame(%v): %s", frame, err)
+	}
+}
+
+func (wt *webDriverT) SwitchToDefaultContent() {
+	if err := wt.d.SwitchToDefaultContent(); err != nil {
+		fatalf(wt.t, "SwitchToDefaultContent(): %s", err)
 	}
 }
 
@@ -259,9 +278,10 @@ func (wt *webDriverT) QAll(sel string) (elems []WebElementT) {
 	return wt.FindElements(ByCSSSelector, sel)
 }
 
-func (wt *webDriverT) ActiveElement() (elem WebElement) {
-	var err error
-	if elem, err = wt.d.ActiveElement(); err != nil {
+func (wt *webDriverT) ActiveElement() (elem WebElementT) {
+	if elem_, err := wt.d.ActiveElement(); err == nil {
+		elem = elem_.T(wt.t)
+	} else {
 		fatalf(wt.t, "ActiveElement: %s", err)
 	}
 	return
@@ -375,6 +395,16 @@ func (wt *webDriverT) ExecuteScriptAsync(script string, args []interface{}) (res
 	return
 }
 
+func (wt *webDriverT) AssertNoBrokenImages() {
+	broken, err := wt.d.FindBrokenImages()
+	if err != nil {
+		fatalf(wt.t, "FindBrokenImages: %s", err)
+	}
+	if len(broken) > 0 {
+		fatalf(wt.t, "AssertNoBrokenImages: broken images %q", broken)
+	}
+}
+
 // A single-return-value interface to WebElement that is useful when using WebElements in test code.
 // Obtain a WebElementT by calling webElement.T(t), where t *testing.T is the test handle for the
 // current test. The methods of WebElementT call wt.fatalf upon encountering errors instead of using
@@ -386,6 +416,7 @@ type WebElementT interface {
 	SendKeys(keys string)
 	Submit()
 	Clear()
+	ClearAndSendKeys(keys string)
 	MoveTo(xOffset, yOffset int)
 
 	FindElement(by, value string) WebElementT
@@ -405,7 +436,13 @@ type WebElementT interface {
 	Location() *Point
 	LocationInView() *Point
 	Size() *Size
+	Rect() *Rect
 	CSSProperty(name string) string
+	GetProperty(name string) interface{}
+	ScrollIntoView()
+	// ScreenshotImage decodes the element's screenshot, see
+	// WebElement.Screenshot.
+	ScreenshotImage(scroll bool) image.Image
 }
 
 type webElementT struct {
@@ -441,6 +478,12 @@ func (wt *webElementT) Clear() {
 	}
 }
 
+func (wt *webElementT) ClearAndSendKeys(keys string) {
+	if err := wt.e.ClearAndSendKeys(keys); err != nil {
+		fatalf(wt.t, "ClearAndSendKeys(%q): %s", keys, err)
+	}
+}
+
 func (wt *webElementT) MoveTo(xOffset, yOffset int) {
 	if err := wt.e.MoveTo(xOffset, yOffset); err != nil {
 		fatalf(wt.t, "MoveTo(xOffset=%d, yOffset=%d): %s", xOffset, yOffset, err)
@@ -557,6 +600,39 @@ func (wt *webElementT) CSSProperty(name string) (v string) {
 	return
 }
 
+func (wt *webElementT) Rect() (v *Rect) {
+	var err error
+	if v, err = wt.e.Rect(); err != nil {
+		fatalf(wt.t, "Rect: %s", err)
+	}
+	return
+}
+
+func (wt *webElementT) GetProperty(name string) (v interface{}) {
+	var err error
+	if v, err = wt.e.GetProperty(name); err != nil {
+		fatalf(wt.t, "GetProperty(%q): %s", name, err)
+	}
+	return
+}
+
+func (wt *webElementT) ScrollIntoView() {
+	if err := wt.e.ScrollIntoView(); err != nil {
+		fatalf(wt.t, "ScrollIntoView: %s", err)
+	}
+}
+
+func (wt *webElementT) ScreenshotImage(scroll bool) (img image.Image) {
+	data, err := wt.e.Screenshot(scroll)
+	if err == nil {
+		img, err = png.Decode(data)
+	}
+	if err != nil {
+		fatalf(wt.t, "ScreenshotImage(%t): %s", scroll, err)
+	}
+	return
+}
+
 func fatalf(t TestingT, fmtStr string, v ...interface{}) {
 	// Backspace (delete) the file and line that t.Fatalf will add
 	// that points to *this* invocation and replace it with that of
@@ -0,0 +1,153 @@
+package selenium
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrWaitTimeout is returned when the condition of a wait is not met before
+// its timeout.
+var ErrWaitTimeout = errors.New("timeout waiting for condition")
+
+// Condition reports whether what a Wait waits for has happened.
+type Condition func(wd WebDriver) (bool, error)
+
+// Wait polls a Condition, like Selenium's FluentWait. Create one with
+// NewWait.
+type Wait struct {
+	wd       WebDriver
+	timeout  time.Duration
+	interval time.Duration
+	ignored  []error
+}
+
+// minWaitInterval is the shortest interval waits poll at, so that they don't
+// flood the server with commands.
+const minWaitInterval = 10 * time.Millisecond
+
+// waitInterval returns interval, raised to minWaitInterval.
+func waitInterval(interval time.Duration) time.Duration {
+	if interval < minWaitInterval {
+		return minWaitInterval
+	}
+	return interval
+}
+
+// NewWait returns a Wait passing wd, any WebDriver, to its conditions every
+// interval, but no more often than every 10 milliseconds, for up to timeout.
+func NewWait(wd WebDriver, timeout, interval time.Duration) *Wait {
+	return &Wait{wd: wd, timeout: timeout, interval: waitInterval(interval)}
+}
+
+// IgnoreErrors keeps polling when the condition fails with an error matching
+// one of errs, with errors.Is, e.g. ErrNoSuchElement or ErrStaleElement.
+func (w *Wait) IgnoreErrors(errs ...error) *Wait {
+	w.ignored = append(w.ignored, errs...)
+	return w
+}
+
+// Until polls cond every interval until it returns true. It returns the
+// first error of cond that isn't ignored, or ErrWaitTimeout once the timeout
+// has passed.
+func (w *Wait) Until(cond Condition) error {
+	deadline := time.Now().Add(w.timeout)
+	for {
+		ok, err := cond(w.wd)
+		if err == nil && ok {
+			return nil
+		}
+		if err != nil && !w.ignores(err) {
+			return err
+		}
+		if time.Now().After(deadline) {
+			return ErrWaitTimeout
+		}
+		time.Sleep(w.interval)
+	}
+}
+
+func (w *Wait) ignores(err error) bool {
+	for _, target := range w.ignored {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+func (wd *remoteWebDriver) WaitForElement(by, value string, timeout, interval time.Duration) (WebElement, error) {
+	return wd.waitForElement(by, value, false, timeout, interval)
+}
+
+func (wd *remoteWebDriver) WaitForVisibleElement(by, value string, timeout, interval time.Duration) (WebElement, error) {
+	return wd.waitForElement(by, value, true, timeout, interval)
+}
+
+// waitForElement polls FindElement until it finds an element, which must also
+// be displayed if visible is set. Elements that are missing or replaced while
+// polling are waited for, other errors end the wait.
+func (wd *remoteWebDriver) waitForElement(by, value string, visible bool, timeout, interval time.Duration) (WebElement, error) {
+	var elem WebElement
+	err := NewWait(wd, timeout, interval).
+		IgnoreErrors(ErrNoSuchElement, ErrStaleElement).
+		Until(func(wd WebDriver) (bool, error) {
+			var err error
+			if elem, err = wd.FindElement(by, value); err != nil || !visible {
+				return err == nil, err
+			}
+			return elem.IsDisplayed()
+		})
+	if err != nil {
+		return nil, err
+	}
+	return elem, nil
+}
+
+func (wd *remoteWebDriver) WaitForElementGone(by, value string, timeout, interval time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	for {
+		elem, err := wd.FindElement(by, value)
+		if err == nil {
+			var displayed bool
+			if displayed, err = elem.IsDisplayed(); err == nil && !displayed {
+				return nil
+			}
+		}
+		if isNoSuchElement(err) || errors.Is(err, ErrStaleElement) {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		if time.Now().After(deadline) {
+			return ErrWaitTimeout
+		}
+		time.Sleep(waitInterval(interval))
+	}
+}
+
+func (wd *remoteWebDriver) WaitAlertAndAccept(timeout time.Duration) (string, error) {
+	return wd.waitAlert(timeout, wd.AcceptAlert)
+}
+
+func (wd *remoteWebDriver) WaitAlertAndDismiss(timeout time.Duration) (string, error) {
+	return wd.waitAlert(timeout, wd.DismissAlert)
+}
+
+// waitAlert polls until an alert is open, then closes it with done and
+// returns its text.
+func (wd *remoteWebDriver) waitAlert(timeout time.Duration, done func() error) (string, error) {
+	deadline := time.Now().Add(timeout)
+	for {
+		text, err := wd.AlertText()
+		if err == nil {
+			return text, done()
+		}
+		if code, _ := ErrorCode(err); code != 27 {
+			return "", err
+		}
+		if time.Now().After(deadline) {
+			return "", ErrWaitTimeout
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+}